@@ -0,0 +1,240 @@
+package zoomalert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// TemplateConfig defines a named message template
+type TemplateConfig struct {
+	// Text is a Go text/template that renders to the JSON of either a ZoomContent
+	// (with a "head" key) or an Alert (with "title" and "level" keys)
+	Text string `json:"text"`
+	// Bot optionally selects the bot that sends messages rendered from this template
+	Bot string `json:"bot,omitempty"`
+}
+
+// builtinTemplates are always available and may be overridden by configuration
+var builtinTemplates = map[string]TemplateConfig{
+	"alert": {
+		Text: `{
+  "title": {{json (default "Alert" .title)}},
+  "level": {{json (level (default "INFO" .level))}},
+  "text": {{json (default "" .text)}},
+  "fields": [{{range $i, $k := keys .fields}}{{if $i}}, {{end}}{"key": {{json $k}}, "value": {{json (str (index $.fields $k))}}}{{end}}],
+  "footer": {{json (default "" .footer)}}
+}`,
+	},
+	// cloudevent renders a CloudEvent: data's title, level and message when data
+	// is an object, or data itself when it is text
+	"cloudevent": {
+		Text: `{
+  "title": {{json (str (default (default .type .subject) (get .data "title")))}},
+  "level": {{json (level (default (default "INFO" .severity) (get .data "level")))}},
+  "text": {{if eq (printf "%T" .data) "string"}}{{json .data}}{{else}}{{json (str (default "" (default (get .data "message") (get .data "description"))))}}{{end}},
+  "fields": [{"key": "Type", "value": {{json .type}}}, {"key": "Source", "value": {{json .source}}}{{with .subject}}, {"key": "Subject", "value": {{json .}}}{{end}}],
+  "footer": {{json (printf "CloudEvent %s" .id)}}
+}`,
+	},
+}
+
+// templateFuncs are available to every message template
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"default": func(def, val any) any {
+		if val == nil || val == "" {
+			return def
+		}
+		return val
+	},
+	"str":   func(v any) string { return fmt.Sprint(v) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"join": func(sep string, items []any) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+	"get": func(v any, key string) any {
+		m, _ := v.(map[string]any)
+		return m[key]
+	},
+	"keys": func(v any) []string {
+		m, _ := v.(map[string]any)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	},
+	"level": func(v any) string {
+		level, err := ParseAlertLevel(fmt.Sprint(v))
+		if err != nil {
+			return string(AlertLevelInfo)
+		}
+		return string(level)
+	},
+}
+
+// compiledTemplate is a parsed message template
+type compiledTemplate struct {
+	tmpl *template.Template
+	bot  string
+}
+
+// TemplateRegistry holds named message templates
+type TemplateRegistry struct {
+	templates map[string]compiledTemplate
+	mutex     sync.RWMutex
+}
+
+// NewTemplateRegistry creates a registry containing the built-in templates
+func NewTemplateRegistry() *TemplateRegistry {
+	r := &TemplateRegistry{
+		templates: make(map[string]compiledTemplate),
+	}
+	for name, cfg := range builtinTemplates {
+		if err := r.Register(name, cfg); err != nil {
+			panic(fmt.Sprintf("invalid built-in template %s: %v", name, err))
+		}
+	}
+	return r
+}
+
+// Register parses and adds a template, replacing any template with the same name
+func (r *TemplateRegistry) Register(name string, cfg TemplateConfig) error {
+	if name == "" {
+		return fmt.Errorf("template name is required")
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(cfg.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.templates[name] = compiledTemplate{tmpl: tmpl, bot: cfg.Bot}
+	return nil
+}
+
+// Has reports whether a template is registered
+func (r *TemplateRegistry) Has(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, ok := r.templates[name]
+	return ok
+}
+
+// Render executes a template with data and returns the message content and the
+// template's bot (empty when the template doesn't select one)
+func (r *TemplateRegistry) Render(name string, data any) (ZoomContent, string, error) {
+	rendered, err := r.render(name, data)
+	if err != nil {
+		return ZoomContent{}, "", err
+	}
+	return rendered.content, rendered.bot, nil
+}
+
+// renderedTemplate is the output of a template with the attributes policies check
+type renderedTemplate struct {
+	content ZoomContent
+	bot     string
+	// level is set when the template rendered an Alert
+	level AlertLevel
+}
+
+// render executes a template with data
+func (r *TemplateRegistry) render(name string, data any) (renderedTemplate, error) {
+	r.mutex.RLock()
+	compiled, ok := r.templates[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return renderedTemplate{}, fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+	}
+
+	var buf bytes.Buffer
+	if err := compiled.tmpl.Execute(&buf, data); err != nil {
+		return renderedTemplate{}, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	content, level, err := decodeTemplateOutput(buf.Bytes())
+	if err != nil {
+		return renderedTemplate{}, fmt.Errorf("template %s: %w", name, err)
+	}
+	return renderedTemplate{content: content, bot: compiled.bot, level: level}, nil
+}
+
+// Names returns the sorted template names
+func (r *TemplateRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decodeTemplateOutput turns rendered JSON into ZoomContent, accepting either
+// ZoomContent or Alert JSON. The level is only known for alerts.
+func decodeTemplateOutput(data []byte) (ZoomContent, AlertLevel, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return ZoomContent{}, "", fmt.Errorf("rendered output is not a JSON object: %w", err)
+	}
+
+	if _, isContent := keys["head"]; isContent {
+		var content ZoomContent
+		if err := json.Unmarshal(data, &content); err != nil {
+			return ZoomContent{}, "", fmt.Errorf("invalid message content: %w", err)
+		}
+		return content, "", nil
+	}
+
+	var alert Alert
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return ZoomContent{}, "", fmt.Errorf("invalid alert: %w", err)
+	}
+	if err := alert.Validate(); err != nil {
+		return ZoomContent{}, "", err
+	}
+	return alert.Content(), alert.Level, nil
+}
+
+// LoadTemplateDir reads every *.tmpl file in dir as a template named after the file
+func LoadTemplateDir(dir string) (map[string]TemplateConfig, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	templates := make(map[string]TemplateConfig, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		templates[name] = TemplateConfig{Text: string(data)}
+	}
+	return templates, nil
+}
@@ -0,0 +1,286 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AlertmanagerConfig routes Alertmanager notifications by receiver name
+type AlertmanagerConfig struct {
+	// Receivers maps Alertmanager receiver names to destinations
+	Receivers map[string]Destination `json:"receivers"`
+	// Default receives notifications for receivers not listed above
+	Default *Destination `json:"default,omitempty"`
+}
+
+// AlertmanagerPayload is Alertmanager's webhook payload (version 4)
+type AlertmanagerPayload struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert is a single alert of an Alertmanager notification
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// destination returns where notifications for a receiver go
+func (c *AlertmanagerConfig) destination(receiver string) (Destination, bool) {
+	if dest, ok := c.Receivers[receiver]; ok {
+		return dest, true
+	}
+	if c.Default != nil {
+		return *c.Default, true
+	}
+	return Destination{}, false
+}
+
+// validate checks every receiver destination
+func (c *AlertmanagerConfig) validate() error {
+	for name, dest := range c.Receivers {
+		if err := dest.validate(); err != nil {
+			return fmt.Errorf("receiver %q: %w", name, err)
+		}
+	}
+	if c.Default != nil {
+		if err := c.Default.validate(); err != nil {
+			return fmt.Errorf("default receiver: %w", err)
+		}
+	}
+	return nil
+}
+
+// AlertmanagerWebhook receives Alertmanager notifications and forwards each group as one message
+func (h *AlertHandler) AlertmanagerWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.Alertmanager
+	if cfg == nil {
+		integrationNotConfigured(w, "Alertmanager")
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	var payload AlertmanagerPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, AlertResponse{
+			Success: false,
+			Message: "Invalid Alertmanager payload",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if len(payload.Alerts) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	dest, ok := cfg.destination(payload.Receiver)
+	if !ok {
+		noDestination(w, fmt.Sprintf("receiver %q", payload.Receiver))
+		return
+	}
+
+	h.deliverIntegration(w, r, dest, renderAlertmanager(payload))
+}
+
+// renderAlertmanager renders a notification group: common labels once, then each
+// alert with its annotations, remaining labels and source/silence links
+func renderAlertmanager(p AlertmanagerPayload) message {
+	resolved := p.Status == "resolved"
+
+	level := AlertLevelInfo
+	firing := 0
+	for _, alert := range p.Alerts {
+		if alert.Status != "resolved" {
+			firing++
+			level = maxLevel(level, levelFromSeverity(alert.Labels["severity"]))
+		}
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text: fmt.Sprintf("[%s:%d] %s", strings.ToUpper(p.Status), len(p.Alerts), alertmanagerGroupTitle(p)),
+			Style: ZoomStyle{
+				Color: level.Color(),
+				Bold:  true,
+			},
+			SubHead: ZoomSubhead{
+				Text: fmt.Sprintf("%s · %d firing · receiver %s", level, firing, p.Receiver),
+			},
+		},
+		Body: []any{},
+	}
+	if resolved {
+		content.Head.Style.Color = resolvedColor
+		content.Head.SubHead.Text = "RESOLVED · receiver " + p.Receiver
+		level = AlertLevelInfo
+	}
+
+	if common := labelFields(p.CommonLabels, nil); len(common) > 0 {
+		content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: common})
+	}
+	if text := annotationText(p.CommonAnnotations); text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: text, Markdown: true})
+	}
+
+	for i, alert := range p.Alerts {
+		if i == maxIntegrationItems {
+			content.Body = append(content.Body, Message{
+				Type: "message",
+				Text: fmt.Sprintf("_…and %d more alerts_", len(p.Alerts)-maxIntegrationItems),
+			})
+			break
+		}
+		content.Body = append(content.Body, alertmanagerAlertBlocks(p, alert)...)
+	}
+
+	footer := "Alertmanager"
+	if p.TruncatedAlerts > 0 {
+		footer += fmt.Sprintf(" · %d alerts truncated", p.TruncatedAlerts)
+	}
+	content.Footer = ZoomFooter{Text: footer}
+
+	return message{content: content, level: level}
+}
+
+// alertmanagerAlertBlocks renders one alert of a group
+func alertmanagerAlertBlocks(p AlertmanagerPayload, alert AlertmanagerAlert) []any {
+	name := alert.Labels["alertname"]
+	if name == "" {
+		name = "Alert"
+	}
+
+	text := alertHeadline(name, alert.Status, alert.StartsAt, alert.EndsAt)
+	// Annotations shared by the whole group were already rendered once
+	if annotations := annotationText(withoutCommon(alert.Annotations, p.CommonAnnotations)); annotations != "" {
+		text += "\n" + annotations
+	}
+
+	blocks := []any{Message{Type: "message", Text: text, Markdown: true}}
+	if fields := labelFields(alert.Labels, p.CommonLabels); len(fields) > 0 {
+		blocks = append(blocks, FieldsBlock{Type: "fields", Items: fields})
+	}
+
+	var actions []Action
+	if alert.GeneratorURL != "" {
+		actions = append(actions, LinkAction("Source", alert.GeneratorURL))
+	}
+	if p.ExternalURL != "" && alert.Status != "resolved" {
+		actions = append(actions, LinkAction("Silence", alertmanagerSilenceURL(p.ExternalURL, alert.Labels)))
+	}
+	if len(actions) > 0 {
+		blocks = append(blocks, ActionsBlock{Type: "actions", Items: actions})
+	}
+	return blocks
+}
+
+// alertmanagerGroupTitle names a group by its alertname and other grouping labels
+func alertmanagerGroupTitle(p AlertmanagerPayload) string {
+	title := p.GroupLabels["alertname"]
+	if title == "" {
+		title = p.CommonLabels["alertname"]
+	}
+
+	var extra []string
+	for _, key := range sortedKeys(p.GroupLabels) {
+		if key != "alertname" {
+			extra = append(extra, key+"="+p.GroupLabels[key])
+		}
+	}
+	if len(extra) > 0 {
+		if title != "" {
+			title += " "
+		}
+		title += "(" + strings.Join(extra, ", ") + ")"
+	}
+	if title == "" {
+		title = "Alertmanager notification"
+	}
+	return title
+}
+
+// alertmanagerSilenceURL links to Alertmanager's new-silence form prefilled with the labels
+func alertmanagerSilenceURL(externalURL string, labels map[string]string) string {
+	var matchers []string
+	for _, key := range sortedKeys(labels) {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+	filter := "{" + strings.Join(matchers, ",") + "}"
+	return strings.TrimSuffix(externalURL, "/") + "/#/silences/new?filter=" + url.QueryEscape(filter)
+}
+
+// labelFields renders labels as fields, skipping alertname and labels listed in skip
+func labelFields(labels, skip map[string]string) []Field {
+	var fields []Field
+	for _, key := range sortedKeys(labels) {
+		if key == "alertname" {
+			continue
+		}
+		if _, common := skip[key]; common {
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: labels[key]})
+	}
+	return fields
+}
+
+// annotationText renders the summary and description annotations (falling back to
+// message) as markdown, followed by any runbook link
+func annotationText(annotations map[string]string) string {
+	var lines []string
+	if summary := annotations["summary"]; summary != "" {
+		lines = append(lines, "**"+summary+"**")
+	}
+	if description := annotations["description"]; description != "" {
+		lines = append(lines, description)
+	} else if msg := annotations["message"]; msg != "" {
+		lines = append(lines, msg)
+	}
+	if runbook := annotations["runbook_url"]; runbook != "" {
+		lines = append(lines, "Runbook: "+runbook)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withoutCommon returns the entries of m that differ from common
+func withoutCommon(m, common map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if common[k] != v {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
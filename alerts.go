@@ -0,0 +1,134 @@
+package zoomalert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AlertLevel is the severity of an alert
+type AlertLevel string
+
+// Supported alert levels, from least to most severe
+const (
+	AlertLevelInfo     AlertLevel = "INFO"
+	AlertLevelWarning  AlertLevel = "WARNING"
+	AlertLevelError    AlertLevel = "ERROR"
+	AlertLevelCritical AlertLevel = "CRITICAL"
+)
+
+// alertLevelColors are the header colors used for each level
+var alertLevelColors = map[AlertLevel]string{
+	AlertLevelInfo:     "#0B5CFF",
+	AlertLevelWarning:  "#F5A623",
+	AlertLevelError:    "#D0021B",
+	AlertLevelCritical: "#8B0000",
+}
+
+// ParseAlertLevel parses a level name case-insensitively
+func ParseAlertLevel(s string) (AlertLevel, error) {
+	level := AlertLevel(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := alertLevelColors[level]; !ok {
+		return "", fmt.Errorf("unknown alert level %q", s)
+	}
+	return level, nil
+}
+
+// Rank orders levels by severity (INFO is 1, CRITICAL is 4, unknown levels are 0)
+func (l AlertLevel) Rank() int {
+	switch l {
+	case AlertLevelInfo:
+		return 1
+	case AlertLevelWarning:
+		return 2
+	case AlertLevelError:
+		return 3
+	case AlertLevelCritical:
+		return 4
+	}
+	return 0
+}
+
+// Color returns the header color for the level
+func (l AlertLevel) Color() string {
+	if color, ok := alertLevelColors[l]; ok {
+		return color
+	}
+	return alertLevelColors[AlertLevelInfo]
+}
+
+// Alert is a severity-tagged alert that renders into ZoomContent
+type Alert struct {
+	Title   string     `json:"title"`
+	Level   AlertLevel `json:"level"`
+	Text    string     `json:"text,omitempty"`
+	Fields  []Field    `json:"fields,omitempty"`
+	Actions []Action   `json:"actions,omitempty"`
+	Footer  string     `json:"footer,omitempty"`
+}
+
+// Validate checks the alert has a title and a known level
+func (a Alert) Validate() error {
+	if a.Title == "" {
+		return fmt.Errorf("alert title is required")
+	}
+	if _, err := ParseAlertLevel(string(a.Level)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Content renders the alert as a Zoom chatbot message
+func (a Alert) Content() ZoomContent {
+	level := a.Level
+	if level == "" {
+		level = AlertLevelInfo
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text: a.Title,
+			Style: ZoomStyle{
+				Color: level.Color(),
+				Bold:  true,
+			},
+			SubHead: ZoomSubhead{
+				Text: string(level),
+			},
+		},
+		Body: []any{},
+		Footer: ZoomFooter{
+			Text: a.Footer,
+		},
+	}
+
+	if a.Text != "" {
+		content.Body = append(content.Body, Message{
+			Type:     "message",
+			Text:     a.Text,
+			Markdown: true,
+		})
+	}
+	if len(a.Fields) > 0 {
+		content.Body = append(content.Body, FieldsBlock{
+			Type:  "fields",
+			Items: a.Fields,
+		})
+	}
+	if len(a.Actions) > 0 {
+		content.Body = append(content.Body, ActionsBlock{
+			Type:  "actions",
+			Items: a.Actions,
+		})
+	}
+
+	return content
+}
+
+// LinkAction builds an action button whose value is the URL it points to
+func LinkAction(text, url string) Action {
+	return Action{
+		Text:  text,
+		Value: url,
+		Style: "Default",
+	}
+}
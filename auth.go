@@ -0,0 +1,287 @@
+package zoomalert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers used to authenticate alert requests
+const (
+	APIKeyHeader            = "X-API-Key"
+	HMACClientHeader        = "X-Client-ID"
+	HMACTimestampHeader     = "X-Timestamp"
+	HMACSignatureHeader     = "X-Signature"
+	hmacSignatureVersion    = "v1"
+	maxSignedBodyBytes      = 1 << 20
+	defaultHMACReplayWindow = 5 * time.Minute
+)
+
+// Authentication methods reported in Client.Method
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodHMAC   = "hmac"
+)
+
+// APIKey is an accepted API key. Only the SHA-256 hash of the key is configured.
+type APIKey struct {
+	// Label identifies the key's owner in logs and audit records
+	Label string `json:"label"`
+	// Hash is the hex SHA-256 of the key (see HashAPIKey)
+	Hash string `json:"hash"`
+}
+
+// HMACClient is a client that signs its requests with a shared secret
+type HMACClient struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Client is the authenticated caller of an HTTP request
+type Client struct {
+	// ID is the API key label or HMAC client ID
+	ID string `json:"id"`
+	// Method is AuthMethodAPIKey or AuthMethodHMAC
+	Method string `json:"method"`
+}
+
+// clientContextKey stores the authenticated Client in a request context
+type clientContextKey struct{}
+
+// ClientFromContext returns the authenticated client of a request, if any
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientContextKey{}).(Client)
+	return client, ok
+}
+
+// HashAPIKey returns the hash to configure for an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticator verifies API keys and HMAC signatures
+type authenticator struct {
+	apiKeys     map[string]string // key hash -> label
+	hmacSecrets map[string][]byte // client ID -> secret
+	window      time.Duration
+	seen        map[string]time.Time // signature -> expiry, for replay protection
+	seenMutex   sync.Mutex
+	logger      *slog.Logger
+}
+
+// newAuthenticator builds an authenticator from config, or returns nil when no
+// credentials are configured (authentication disabled)
+func newAuthenticator(config *Config, logger *slog.Logger) *authenticator {
+	if len(config.APIKeys) == 0 && len(config.HMACClients) == 0 {
+		return nil
+	}
+
+	a := &authenticator{
+		apiKeys:     make(map[string]string, len(config.APIKeys)),
+		hmacSecrets: make(map[string][]byte, len(config.HMACClients)),
+		window:      config.HMACReplayWindow,
+		seen:        make(map[string]time.Time),
+		logger:      logger,
+	}
+	if a.window <= 0 {
+		a.window = defaultHMACReplayWindow
+	}
+	for _, key := range config.APIKeys {
+		a.apiKeys[strings.ToLower(key.Hash)] = key.Label
+	}
+	for _, client := range config.HMACClients {
+		a.hmacSecrets[client.ID] = []byte(client.Secret)
+	}
+	return a
+}
+
+// middleware rejects unauthenticated requests and stores the Client in the request context
+func (a *authenticator) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, err := a.authenticate(r)
+		if err != nil {
+			a.logger.Warn("Rejected unauthenticated request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="zoom-alert"`)
+			writeJSON(w, http.StatusUnauthorized, jsonObject{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), clientContextKey{}, client)))
+	}
+}
+
+// authenticate identifies the caller from an API key or an HMAC signature
+func (a *authenticator) authenticate(r *http.Request) (Client, error) {
+	if key := requestAPIKey(r); key != "" {
+		label, ok := a.apiKeys[HashAPIKey(key)]
+		if !ok {
+			return Client{}, fmt.Errorf("invalid API key")
+		}
+		return Client{ID: label, Method: AuthMethodAPIKey}, nil
+	}
+
+	if r.Header.Get(HMACSignatureHeader) != "" {
+		return a.verifyHMAC(r)
+	}
+
+	return Client{}, fmt.Errorf("missing credentials")
+}
+
+// requestAPIKey returns the key from X-API-Key or an Authorization: Bearer header
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// verifyHMAC checks X-Signature = "v1=" + hex(HMAC-SHA256(secret, "v1:<timestamp>:<method>:<request URI>:<body>"))
+// and rejects stale timestamps and replayed signatures
+func (a *authenticator) verifyHMAC(r *http.Request) (Client, error) {
+	clientID := r.Header.Get(HMACClientHeader)
+	secret, ok := a.hmacSecrets[clientID]
+	if !ok {
+		return Client{}, fmt.Errorf("unknown HMAC client %q", clientID)
+	}
+
+	timestamp := r.Header.Get(HMACTimestampHeader)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Client{}, fmt.Errorf("invalid %s header", HMACTimestampHeader)
+	}
+	now := time.Now()
+	if drift := now.Sub(time.Unix(ts, 0)); drift > a.window || drift < -a.window {
+		return Client{}, fmt.Errorf("request timestamp is outside the replay window")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+	if err != nil {
+		return Client{}, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) > maxSignedBodyBytes {
+		return Client{}, fmt.Errorf("request body too large")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s:%s:%s:%s:", hmacSignatureVersion, timestamp, r.Method, r.RequestURI)
+	mac.Write(body)
+	expected := hmacSignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+
+	signature := r.Header.Get(HMACSignatureHeader)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) != 1 {
+		return Client{}, fmt.Errorf("invalid request signature")
+	}
+
+	if !a.markSeen(signature, now) {
+		return Client{}, fmt.Errorf("replayed request signature")
+	}
+
+	return Client{ID: clientID, Method: AuthMethodHMAC}, nil
+}
+
+// markSeen records a signature for the replay window, returning false if it was already used
+func (a *authenticator) markSeen(signature string, now time.Time) bool {
+	a.seenMutex.Lock()
+	defer a.seenMutex.Unlock()
+
+	for sig, expiry := range a.seen {
+		if now.After(expiry) {
+			delete(a.seen, sig)
+		}
+	}
+	if _, used := a.seen[signature]; used {
+		return false
+	}
+	// A timestamp may drift either way, so a signature stays valid for twice the window
+	a.seen[signature] = now.Add(2 * a.window)
+	return true
+}
+
+// validateAuth checks configured API key hashes and HMAC clients
+func validateAuth(c *Config) error {
+	labels := make(map[string]bool)
+	for _, key := range c.APIKeys {
+		if key.Label == "" {
+			return fmt.Errorf("API key label is required")
+		}
+		if labels[key.Label] {
+			return fmt.Errorf("duplicate API key label %q", key.Label)
+		}
+		labels[key.Label] = true
+		if raw, err := hex.DecodeString(key.Hash); err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("API key %q: hash must be a hex SHA-256 digest", key.Label)
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, client := range c.HMACClients {
+		if client.ID == "" {
+			return fmt.Errorf("HMAC client ID is required")
+		}
+		if ids[client.ID] {
+			return fmt.Errorf("duplicate HMAC client %q", client.ID)
+		}
+		ids[client.ID] = true
+		if len(client.Secret) < 32 {
+			return fmt.Errorf("HMAC client %q: secret must be at least 32 characters", client.ID)
+		}
+	}
+	return nil
+}
+
+// loadAuthFromEnv reads ALERT_API_KEYS ("label:sha256hex,...") and the clients listed
+// in ALERT_HMAC_CLIENTS with their ALERT_HMAC_CLIENT_<ID>_SECRET
+func loadAuthFromEnv(config *Config) {
+	for _, entry := range splitList(os.Getenv("ALERT_API_KEYS")) {
+		label, hash, ok := strings.Cut(entry, ":")
+		if !ok {
+			slog.Warn("Ignoring API key without a label", "entry_length", len(entry))
+			continue
+		}
+		config.APIKeys = append(config.APIKeys, APIKey{Label: label, Hash: hash})
+	}
+
+	for _, id := range splitList(os.Getenv("ALERT_HMAC_CLIENTS")) {
+		config.HMACClients = append(config.HMACClients, HMACClient{
+			ID:     id,
+			Secret: os.Getenv("ALERT_HMAC_CLIENT_" + envName(id) + "_SECRET"),
+		})
+	}
+
+	if val := os.Getenv("ALERT_HMAC_REPLAY_WINDOW"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.HMACReplayWindow = d
+		} else {
+			slog.Warn("Ignoring invalid ALERT_HMAC_REPLAY_WINDOW", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("ALERT_AUTH_OAUTH"); val != "" {
+		config.AuthProtectOAuth = parseBool(val)
+	}
+	if val := os.Getenv("ALERT_AUTH_HEALTH"); val != "" {
+		config.AuthProtectHealth = parseBool(val)
+	}
+}
@@ -0,0 +1,120 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// zoomRefreshTokenLifetime is how long Zoom keeps a refresh token valid after issuing it
+const zoomRefreshTokenLifetime = 90 * 24 * time.Hour
+
+// DefaultRequiredScopes are the scopes needed for user lookup and chat messages
+var DefaultRequiredScopes = []string{"user:read", "chat_message:write"}
+
+// AuthStatus describes the current user authorization
+type AuthStatus struct {
+	UserAuthorized        bool       `json:"user_authorized"`
+	AuthorizedBy          *User      `json:"authorized_by,omitempty"`
+	AuthorizedAt          *time.Time `json:"authorized_at,omitempty"`
+	GrantedScopes         []string   `json:"granted_scopes"`
+	RequiredScopes        []string   `json:"required_scopes"`
+	MissingScopes         []string   `json:"missing_scopes"`
+	AccessTokenExpiresAt  *time.Time `json:"access_token_expires_at,omitempty"`
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at,omitempty"`
+}
+
+// Status returns a snapshot of the user authorization, refreshing the token if needed
+func (o *OAuthService) Status() AuthStatus {
+	authorized := o.IsUserAuthorized()
+
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
+	status := AuthStatus{
+		UserAuthorized: authorized,
+		AuthorizedBy:   o.authorizedBy,
+		GrantedScopes:  append([]string{}, o.grantedScopes...),
+		RequiredScopes: append([]string{}, o.config.RequiredScopes...),
+		MissingScopes:  missingScopes(o.grantedScopes, o.config.RequiredScopes),
+	}
+	if !o.authorizedAt.IsZero() {
+		status.AuthorizedAt = timePtr(o.authorizedAt)
+	}
+	if !o.userExpiresAt.IsZero() {
+		status.AccessTokenExpiresAt = timePtr(o.userExpiresAt)
+	}
+	if !o.refreshExpiresAt.IsZero() {
+		status.RefreshTokenExpiresAt = timePtr(o.refreshExpiresAt)
+	}
+
+	return status
+}
+
+// authorizer returns the user who authorized the stored tokens, if known
+func (o *OAuthService) authorizer() *User {
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+	return o.authorizedBy
+}
+
+// fetchAuthorizer looks up the Zoom user that owns an access token
+func fetchAuthorizer(token string) (*User, error) {
+	req, err := http.NewRequest("GET", "https://api.zoom.us/v2/users/me", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &user, nil
+}
+
+// parseScopes splits the space-separated scope string of a token response
+func parseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// missingScopes returns the required scopes not covered by the granted ones.
+// A granted scope covers a required one when equal or more specific
+// (e.g. "user:read:admin" covers "user:read").
+func missingScopes(granted, required []string) []string {
+	missing := []string{}
+	for _, req := range required {
+		covered := false
+		for _, g := range granted {
+			if g == req || strings.HasPrefix(g, req+":") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// timePtr returns a pointer to a copy of t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,187 @@
+package zoomalert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testAPIKey     = "test-api-key"
+	testHMACClient = "ci"
+	testHMACSecret = "shared-secret"
+)
+
+func newTestAuthenticator() *authenticator {
+	return newAuthenticator(&Config{
+		APIKeys:     []APIKey{{Label: "ops", Hash: strings.ToUpper(HashAPIKey(testAPIKey))}},
+		HMACClients: []HMACClient{{ID: testHMACClient, Secret: testHMACSecret}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// signHMAC sets the HMAC headers the way a client signs a request
+func signHMAC(r *http.Request, clientID, secret string, timestamp time.Time, body string) {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s:%s:%s:%s:%s", hmacSignatureVersion, ts, r.Method, r.RequestURI, body)
+	r.Header.Set(HMACClientHeader, clientID)
+	r.Header.Set(HMACTimestampHeader, ts)
+	r.Header.Set(HMACSignatureHeader, hmacSignatureVersion+"="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	const body = `{"message":"deploy finished"}`
+
+	tests := []struct {
+		name    string
+		prepare func(r *http.Request)
+		// replay sends the same request a second time and checks that response
+		replay     bool
+		wantStatus int
+		wantClient Client
+	}{
+		{
+			name:       "valid API key",
+			prepare:    func(r *http.Request) { r.Header.Set(APIKeyHeader, testAPIKey) },
+			wantStatus: http.StatusOK,
+			wantClient: Client{ID: "ops", Method: AuthMethodAPIKey},
+		},
+		{
+			name:       "valid bearer token",
+			prepare:    func(r *http.Request) { r.Header.Set("Authorization", "bearer "+testAPIKey) },
+			wantStatus: http.StatusOK,
+			wantClient: Client{ID: "ops", Method: AuthMethodAPIKey},
+		},
+		{
+			name:       "unknown API key",
+			prepare:    func(r *http.Request) { r.Header.Set(APIKeyHeader, "not-a-key") },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing credentials",
+			prepare:    func(r *http.Request) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "valid HMAC signature",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now(), body)
+			},
+			wantStatus: http.StatusOK,
+			wantClient: Client{ID: testHMACClient, Method: AuthMethodHMAC},
+		},
+		{
+			name: "unknown HMAC client",
+			prepare: func(r *http.Request) {
+				signHMAC(r, "intruder", testHMACSecret, time.Now(), body)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong HMAC secret",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, "guessed", time.Now(), body)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now(), `{"message":"rollback"}`)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered timestamp",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now(), body)
+				ts, _ := strconv.ParseInt(r.Header.Get(HMACTimestampHeader), 10, 64)
+				r.Header.Set(HMACTimestampHeader, strconv.FormatInt(ts+1, 10))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now().Add(-2*defaultHMACReplayWindow), body)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "future timestamp",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now().Add(2*defaultHMACReplayWindow), body)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "replayed signature",
+			prepare: func(r *http.Request) {
+				signHMAC(r, testHMACClient, testHMACSecret, time.Now(), body)
+			},
+			replay:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got    Client
+				called bool
+			)
+			handler := newTestAuthenticator().middleware(func(w http.ResponseWriter, r *http.Request) {
+				got, called = ClientFromContext(r.Context())
+				// The signed body must still be readable by the handler
+				if b, _ := io.ReadAll(r.Body); string(b) != body {
+					t.Errorf("handler read body %q, want %q", b, body)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			newRequest := func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/api/v1/alert?async=true", strings.NewReader(body))
+			}
+			req := newRequest()
+			tt.prepare(req)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if tt.replay {
+				if rec.Code != http.StatusOK {
+					t.Fatalf("first request status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+				}
+				called = false
+				replayed := newRequest()
+				replayed.Header = req.Header.Clone()
+				rec = httptest.NewRecorder()
+				handler(rec, replayed)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if called {
+					t.Fatal("handler called for a rejected request")
+				}
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Error("missing WWW-Authenticate header")
+				}
+				return
+			}
+			if !called || got != tt.wantClient {
+				t.Fatalf("client = %+v (set %v), want %+v", got, called, tt.wantClient)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package zoomalert
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultBotName selects the bot configured by ZOOM_ROBOT_JID
+const DefaultBotName = "default"
+
+// BotConfig describes a Zoom chatbot that messages can be sent from
+type BotConfig struct {
+	RobotJID string `json:"robot_jid"`
+	// ClientID and ClientSecret are optional; bots without them use the account's credentials
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// cachedToken is a client-credentials token and its expiry
+type cachedToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// bot returns the bot registered under name; an empty name or "default"
+// selects ZOOM_ROBOT_JID unless a bot named "default" is configured
+func (z *ZoomService) bot(name string) (BotConfig, error) {
+	if name == "" {
+		name = DefaultBotName
+	}
+	if bot, ok := z.bots[name]; ok {
+		return bot, nil
+	}
+	if name == DefaultBotName {
+		return BotConfig{RobotJID: z.robotJID}, nil
+	}
+	return BotConfig{}, fmt.Errorf("%w %q", ErrUnknownBot, name)
+}
+
+// BotNames returns the names of the bots this service can send from
+func (z *ZoomService) BotNames() []string {
+	names := []string{}
+	if _, ok := z.bots[DefaultBotName]; !ok && z.robotJID != "" {
+		names = append(names, DefaultBotName)
+	}
+	for name := range z.bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateBots checks the bot registry of a configuration
+func validateBots(bots map[string]BotConfig) error {
+	for name, bot := range bots {
+		if bot.RobotJID == "" {
+			return fmt.Errorf("bot %s: robot JID is required", name)
+		}
+		if (bot.ClientID == "") != (bot.ClientSecret == "") {
+			return fmt.Errorf("bot %s: client ID and client secret must be set together", name)
+		}
+	}
+	return nil
+}
+
+// loadBotConfigsFromEnv reads the bots listed in ZOOM_BOTS from
+// ZOOM_BOT_<NAME>_ROBOT_JID, _CLIENT_ID and _CLIENT_SECRET
+func loadBotConfigsFromEnv(prefix string) map[string]BotConfig {
+	names := splitList(os.Getenv(prefix + "BOTS"))
+	if len(names) == 0 {
+		return nil
+	}
+
+	bots := make(map[string]BotConfig, len(names))
+	for _, name := range names {
+		botPrefix := prefix + "BOT_" + envName(name) + "_"
+		bots[name] = BotConfig{
+			RobotJID:     os.Getenv(botPrefix + "ROBOT_JID"),
+			ClientID:     os.Getenv(botPrefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(botPrefix + "CLIENT_SECRET"),
+		}
+	}
+	return bots
+}
@@ -0,0 +1,191 @@
+package zoomalert
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RepoRoute sends CI/CD events of matching repositories and branches to a destination
+type RepoRoute struct {
+	// Repository is a glob matched against "owner/name" (GitHub) or the
+	// project path (GitLab); empty matches every repository
+	Repository string `json:"repository,omitempty"`
+	// Branch is a glob matched against the branch or ref; empty matches every branch
+	Branch string `json:"branch,omitempty"`
+	// OnlyFailures skips successful runs and deployments
+	OnlyFailures bool `json:"only_failures,omitempty"`
+	Destination
+}
+
+// matches reports whether the route applies to a repository and branch
+func (rt RepoRoute) matches(repository, branch string) bool {
+	if rt.Repository != "" {
+		if ok, _ := path.Match(rt.Repository, repository); !ok {
+			return false
+		}
+	}
+	if rt.Branch != "" {
+		if ok, _ := path.Match(rt.Branch, branch); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// CICDConfig configures a CI/CD event receiver
+type CICDConfig struct {
+	// Secret verifies deliveries: GitHub's webhook secret or GitLab's secret token
+	Secret string `json:"secret"`
+	// Routes are tried in order; the first match receives the event
+	Routes []RepoRoute `json:"routes"`
+	// Default receives events that match no route
+	Default *Destination `json:"default,omitempty"`
+}
+
+// route returns the destination for an event, or false if it should be dropped
+func (c *CICDConfig) route(ev pipelineEvent) (Destination, bool) {
+	for _, rt := range c.Routes {
+		if !rt.matches(ev.Repository, ev.Branch) {
+			continue
+		}
+		if rt.OnlyFailures && ev.outcome() == outcomeSuccess {
+			return Destination{}, false
+		}
+		return rt.Destination, true
+	}
+	if c.Default != nil {
+		return *c.Default, true
+	}
+	return Destination{}, false
+}
+
+// validate checks the secret, route patterns and destinations
+func (c *CICDConfig) validate() error {
+	if c.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	for i, rt := range c.Routes {
+		for _, pattern := range []string{rt.Repository, rt.Branch} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("route %d: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+		if err := rt.Destination.validate(); err != nil {
+			return fmt.Errorf("route %d: %w", i, err)
+		}
+	}
+	if c.Default != nil {
+		if err := c.Default.validate(); err != nil {
+			return fmt.Errorf("default destination: %w", err)
+		}
+	}
+	return nil
+}
+
+// Normalized outcomes of CI/CD runs
+const (
+	outcomeSuccess   = "success"
+	outcomeFailure   = "failure"
+	outcomeCancelled = "cancelled"
+)
+
+// pipelineEvent is a finished workflow run, pipeline, check suite or deployment,
+// normalized across providers
+type pipelineEvent struct {
+	Provider   string
+	Kind       string
+	Name       string
+	Status     string
+	Repository string
+	RepoURL    string
+	Branch     string
+	Commit     string
+	CommitURL  string
+	CommitText string
+	Author     string
+	RunURL     string
+	// Environment and EnvironmentURL are set for deployments
+	Environment    string
+	EnvironmentURL string
+}
+
+// outcome maps provider-specific statuses to success, failure or cancelled
+func (ev pipelineEvent) outcome() string {
+	switch strings.ToLower(ev.Status) {
+	case "success", "succeeded", "neutral", "skipped":
+		return outcomeSuccess
+	case "cancelled", "canceled", "stale":
+		return outcomeCancelled
+	}
+	return outcomeFailure
+}
+
+// renderPipelineEvent renders a success/failure card for a CI/CD event
+func renderPipelineEvent(ev pipelineEvent) message {
+	level, color, verb := AlertLevelError, AlertLevelError.Color(), "failed"
+	switch ev.outcome() {
+	case outcomeSuccess:
+		level, color, verb = AlertLevelInfo, resolvedColor, "succeeded"
+	case outcomeCancelled:
+		level, color, verb = AlertLevelWarning, AlertLevelWarning.Color(), "was cancelled"
+	}
+
+	name := ev.Name
+	if ev.Environment != "" {
+		name = "Deployment to " + ev.Environment
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:    fmt.Sprintf("%s %s", name, verb),
+			Style:   ZoomStyle{Color: color, Bold: true},
+			SubHead: ZoomSubhead{Text: fmt.Sprintf("%s · %s", ev.Repository, ev.Kind)},
+		},
+		Body: []any{},
+	}
+
+	if ev.CommitText != "" {
+		// Only the commit's subject line
+		subject, _, _ := strings.Cut(ev.CommitText, "\n")
+		content.Body = append(content.Body, Message{Type: "message", Text: subject})
+	}
+
+	var fields []Field
+	if ev.Branch != "" {
+		fields = append(fields, Field{Key: "Branch", Value: ev.Branch})
+	}
+	if ev.Commit != "" {
+		fields = append(fields, Field{Key: "Commit", Value: shortSHA(ev.Commit)})
+	}
+	if ev.Author != "" {
+		fields = append(fields, Field{Key: "Author", Value: ev.Author})
+	}
+	fields = append(fields, Field{Key: "Status", Value: ev.Status})
+	content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: fields})
+
+	var actions []Action
+	if ev.RunURL != "" {
+		actions = append(actions, LinkAction("View run", ev.RunURL))
+	}
+	if ev.CommitURL != "" {
+		actions = append(actions, LinkAction("Commit", ev.CommitURL))
+	}
+	if ev.EnvironmentURL != "" {
+		actions = append(actions, LinkAction("Open "+ev.Environment, ev.EnvironmentURL))
+	}
+	if len(actions) > 0 {
+		content.Body = append(content.Body, ActionsBlock{Type: "actions", Items: actions})
+	}
+
+	content.Footer = ZoomFooter{Text: ev.Provider}
+	return message{content: content, level: level}
+}
+
+// shortSHA abbreviates a commit hash
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
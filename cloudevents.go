@@ -0,0 +1,365 @@
+package zoomalert
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// CloudEvents content types of the structured and batched HTTP modes
+const (
+	cloudEventsContentType      = "application/cloudevents+json"
+	cloudEventsBatchContentType = "application/cloudevents-batch+json"
+	cloudEventsHeaderPrefix     = "Ce-"
+	cloudEventsSpecVersion      = "1.0"
+)
+
+// defaultCloudEventTemplate renders events whose rule names no template
+const defaultCloudEventTemplate = "cloudevent"
+
+// errCloudEventFormat is returned for event formats other than JSON
+var errCloudEventFormat = errors.New("unsupported CloudEvents event format")
+
+// maxCloudEventBatch bounds the events accepted in one batch
+const maxCloudEventBatch = 100
+
+// CloudEventsConfig routes CloudEvents by their context attributes
+type CloudEventsConfig struct {
+	// Rules are tried in order; the first match handles the event. A rule
+	// without patterns matches every event.
+	Rules []CloudEventRule `json:"rules"`
+}
+
+// CloudEventRule matches events by type, source and subject globs and says how to
+// render and where to send them
+type CloudEventRule struct {
+	Type    string `json:"type,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	// Template renders the event; defaults to the built-in "cloudevent" template
+	Template string `json:"template,omitempty"`
+	// Drop acknowledges matching events without sending anything
+	Drop bool `json:"drop,omitempty"`
+	Destination
+}
+
+// matches reports whether the rule applies to an event
+func (rule CloudEventRule) matches(ev cloudEvent) bool {
+	for _, check := range [][2]string{
+		{rule.Type, ev.attributes["type"]},
+		{rule.Source, ev.attributes["source"]},
+		{rule.Subject, ev.attributes["subject"]},
+	} {
+		if check[0] == "" {
+			continue
+		}
+		if ok, _ := path.Match(check[0], check[1]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// rule returns the first rule matching an event
+func (c *CloudEventsConfig) rule(ev cloudEvent) (CloudEventRule, bool) {
+	for _, rule := range c.Rules {
+		if rule.matches(ev) {
+			return rule, true
+		}
+	}
+	return CloudEventRule{}, false
+}
+
+// validate checks rule patterns and destinations
+func (c *CloudEventsConfig) validate() error {
+	for i, rule := range c.Rules {
+		for _, pattern := range []string{rule.Type, rule.Source, rule.Subject} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %d: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+		if rule.Drop {
+			continue
+		}
+		if err := rule.Destination.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// cloudEvent is a decoded event: its context attributes (including extensions)
+// as strings, and its data
+type cloudEvent struct {
+	attributes map[string]string
+	data       any
+}
+
+// templateData exposes the attributes and data to message templates
+func (ev cloudEvent) templateData() map[string]any {
+	data := make(map[string]any, len(ev.attributes)+1)
+	for name, value := range ev.attributes {
+		data[name] = value
+	}
+	data["data"] = ev.data
+	return data
+}
+
+// validate checks the attributes required by the CloudEvents spec
+func (ev cloudEvent) validate() error {
+	if v := ev.attributes["specversion"]; v != cloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported CloudEvents specversion %q", ErrInvalidMessage, v)
+	}
+	for _, name := range []string{"id", "source", "type"} {
+		if ev.attributes[name] == "" {
+			return fmt.Errorf("%w: CloudEvent attribute %s is required", ErrInvalidMessage, name)
+		}
+	}
+	return nil
+}
+
+// CloudEventResult is the outcome of one event of a batch
+type CloudEventResult struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Status int    `json:"status"`
+	AlertResponse
+}
+
+// CloudEvents receives CloudEvents 1.0 in binary, structured or batched HTTP mode
+func (h *AlertHandler) CloudEvents(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.CloudEvents
+	if cfg == nil {
+		integrationNotConfigured(w, "CloudEvents")
+		return
+	}
+
+	events, batch, err := readCloudEvents(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errCloudEventFormat) {
+			status = http.StatusUnsupportedMediaType
+		}
+		writeJSON(w, status, AlertResponse{
+			Success: false,
+			Message: "Invalid CloudEvent",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !batch {
+		ev := events[0]
+		tos, msg, o, err := h.routeCloudEvent(r, cfg, ev)
+		switch {
+		case err != nil:
+			writeSendResults(w, nil, err)
+		case tos == nil:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			h.dispatch(w, r, tos, msg, o)
+		}
+		return
+	}
+
+	// Every event is attempted, so one bad event doesn't hold back the others
+	results := make([]CloudEventResult, 0, len(events))
+	for _, ev := range events {
+		result := CloudEventResult{ID: ev.attributes["id"], Source: ev.attributes["source"]}
+
+		tos, msg, o, err := h.routeCloudEvent(r, cfg, ev)
+		switch {
+		case err != nil:
+			result.Status, result.AlertResponse = sendResponse(nil, err)
+		case tos == nil:
+			result.Status = http.StatusNoContent
+			result.AlertResponse = AlertResponse{Success: true, Message: "Event dropped"}
+		default:
+			sent, job, err := h.submit(r, tos, msg, o)
+			if job != nil {
+				result.Status, result.AlertResponse = jobResponse(job)
+			} else {
+				result.Status, result.AlertResponse = sendResponse(sent, err)
+			}
+		}
+		results = append(results, result)
+	}
+
+	status := batchStatus(results)
+	writeJSON(w, status, jsonObject{
+		"success": status < http.StatusBadRequest,
+		"results": results,
+	})
+}
+
+// batchStatus is the most severe status of a batch's events: the highest failure,
+// else 202 if any event was queued, else 200
+func batchStatus(results []CloudEventResult) int {
+	status := http.StatusOK
+	for _, result := range results {
+		switch {
+		case result.Status >= http.StatusBadRequest:
+			status = max(status, result.Status)
+		case result.Status == http.StatusAccepted && status < http.StatusBadRequest:
+			status = http.StatusAccepted
+		}
+	}
+	return status
+}
+
+// routeCloudEvent finds an event's rule and renders it. It returns nil recipients
+// for dropped events.
+func (h *AlertHandler) routeCloudEvent(r *http.Request, cfg *CloudEventsConfig, ev cloudEvent) ([]Recipient, message, sendOptions, error) {
+	rule, ok := cfg.rule(ev)
+	if !ok {
+		return nil, message{}, sendOptions{}, fmt.Errorf("%w for CloudEvent type %q from %q",
+			ErrNoDestination, ev.attributes["type"], ev.attributes["source"])
+	}
+	if rule.Drop {
+		return nil, message{}, sendOptions{}, nil
+	}
+
+	o := integrationSendOptions(r, rule.Destination)
+	// source and id identify an event, so a redelivered event is sent once
+	o.idempotencyKey = ev.attributes["source"] + "|" + ev.attributes["id"]
+
+	name := rule.Template
+	if name == "" {
+		name = defaultCloudEventTemplate
+	}
+	msg, err := h.dispatcher.templateMessage(name, ev.templateData(), &o)
+	if err != nil {
+		return nil, message{}, sendOptions{}, err
+	}
+	return rule.Destination.recipients(), msg, o, nil
+}
+
+// readCloudEvents decodes the request's events and reports whether it was a batch
+func readCloudEvents(r *http.Request) ([]cloudEvent, bool, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		return nil, false, fmt.Errorf("invalid content type: %w", err)
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case mediaType == cloudEventsBatchContentType:
+		var raw []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, true, fmt.Errorf("invalid batch: %w", err)
+		}
+		if len(raw) == 0 {
+			return nil, true, fmt.Errorf("empty batch")
+		}
+		if len(raw) > maxCloudEventBatch {
+			return nil, true, fmt.Errorf("batch has %d events, at most %d are accepted", len(raw), maxCloudEventBatch)
+		}
+		events := make([]cloudEvent, 0, len(raw))
+		for i, fields := range raw {
+			ev, err := structuredCloudEvent(fields)
+			if err != nil {
+				return nil, true, fmt.Errorf("event %d: %w", i, err)
+			}
+			events = append(events, ev)
+		}
+		return events, true, nil
+
+	case mediaType == cloudEventsContentType:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, false, fmt.Errorf("invalid event: %w", err)
+		}
+		ev, err := structuredCloudEvent(fields)
+		if err != nil {
+			return nil, false, err
+		}
+		return []cloudEvent{ev}, false, nil
+
+	case strings.HasPrefix(mediaType, "application/cloudevents"):
+		return nil, false, fmt.Errorf("%w: %s", errCloudEventFormat, mediaType)
+
+	case r.Header.Get(cloudEventsHeaderPrefix+"Specversion") != "":
+		ev, err := binaryCloudEvent(r.Header, mediaType, body)
+		if err != nil {
+			return nil, false, err
+		}
+		return []cloudEvent{ev}, false, nil
+	}
+	return nil, false, fmt.Errorf("request is not a CloudEvent: expected ce-* headers or content type %s", cloudEventsContentType)
+}
+
+// structuredCloudEvent decodes an event in the JSON event format
+func structuredCloudEvent(fields map[string]json.RawMessage) (cloudEvent, error) {
+	ev := cloudEvent{attributes: make(map[string]string, len(fields))}
+	for name, raw := range fields {
+		switch name {
+		case "data":
+			if err := json.Unmarshal(raw, &ev.data); err != nil {
+				return ev, fmt.Errorf("invalid data: %w", err)
+			}
+		case "data_base64":
+			var encoded string
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return ev, fmt.Errorf("invalid data_base64: %w", err)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return ev, fmt.Errorf("invalid data_base64: %w", err)
+			}
+			ev.data = string(decoded)
+		default:
+			// Attributes may be any JSON scalar; templates see their string form
+			var value any
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return ev, fmt.Errorf("invalid attribute %s: %w", name, err)
+			}
+			if value != nil {
+				ev.attributes[strings.ToLower(name)] = fmt.Sprint(value)
+			}
+		}
+	}
+	return ev, ev.validate()
+}
+
+// binaryCloudEvent decodes an event whose attributes are ce-* headers and whose
+// data is the body
+func binaryCloudEvent(header http.Header, mediaType string, body []byte) (cloudEvent, error) {
+	ev := cloudEvent{attributes: make(map[string]string)}
+	for name, values := range header {
+		if !strings.HasPrefix(name, cloudEventsHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		value, err := url.PathUnescape(values[0])
+		if err != nil {
+			value = values[0]
+		}
+		ev.attributes[strings.ToLower(strings.TrimPrefix(name, cloudEventsHeaderPrefix))] = value
+	}
+	if mediaType != "" {
+		ev.attributes["datacontenttype"] = mediaType
+	}
+	if err := ev.validate(); err != nil {
+		return ev, err
+	}
+
+	switch {
+	case len(body) == 0:
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := json.Unmarshal(body, &ev.data); err != nil {
+			return ev, fmt.Errorf("%w: invalid JSON data: %v", ErrInvalidMessage, err)
+		}
+	default:
+		ev.data = string(body)
+	}
+	return ev, nil
+}
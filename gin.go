@@ -0,0 +1,93 @@
+package zoomalert
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes sets up all module routes (OAuth, alerts and integrations) on an existing Gin router
+func (m *ZoomAlertModule) RegisterRoutes(router *gin.Engine) {
+	m.RegisterOAuthRoutes(router)
+	m.RegisterAlertRoutes(router)
+	m.RegisterIntegrationRoutes(router)
+}
+
+// RegisterOAuthRoutes sets up the OAuth routes on an existing Gin router.
+// Every tenant-aware route is also available under /api/v1/tenants/:tenant.
+func (m *ZoomAlertModule) RegisterOAuthRoutes(router *gin.Engine) {
+	m.registerGinRoutes(router, routeGroupOAuth)
+}
+
+// RegisterAlertRoutes sets up the alert routes on an existing Gin router
+func (m *ZoomAlertModule) RegisterAlertRoutes(router *gin.Engine) {
+	m.registerGinRoutes(router, routeGroupAlert)
+}
+
+// RegisterIntegrationRoutes sets up the webhook receivers (/integrations/...) on an existing Gin router
+func (m *ZoomAlertModule) RegisterIntegrationRoutes(router *gin.Engine) {
+	m.registerGinRoutes(router, routeGroupIntegration)
+}
+
+// registerGinRoutes adds the routes of one group under /api/v1
+func (m *ZoomAlertModule) registerGinRoutes(router *gin.Engine, group routeGroup) {
+	v1 := router.Group(apiPrefix)
+	for _, rt := range m.routes() {
+		if rt.group != group {
+			continue
+		}
+		v1.Handle(rt.method, ginPath(rt.path), ginHandler(rt.handler))
+	}
+}
+
+// ginPath converts net/http wildcards ({tenant}) to Gin parameters (:tenant)
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			segments[i] = ":" + strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ginHandler adapts a net/http handler to Gin, exposing Gin path parameters
+// through Request.PathValue
+func ginHandler(h http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range c.Params {
+			c.Request.SetPathValue(p.Key, p.Value)
+		}
+		h(c.Writer, c.Request)
+	}
+}
+
+// The AlertHandler methods below keep the Gin signatures of earlier releases for
+// routers that mount them directly. New code should use RegisterRoutes or
+// ZoomAlertModule.Handler, which also serve the tenant routes.
+
+// SendAlert is the Gin handler for POST /alert
+func (h *AlertHandler) SendAlert(c *gin.Context) {
+	ginHandler(h.sendAlert)(c)
+}
+
+// HealthCheck is the Gin handler for GET /health
+func (h *AlertHandler) HealthCheck(c *gin.Context) {
+	ginHandler(h.healthCheck)(c)
+}
+
+// OAuthAuthorize is the Gin handler for GET /oauth/authorize
+func (h *AlertHandler) OAuthAuthorize(c *gin.Context) {
+	ginHandler(h.oauthAuthorize)(c)
+}
+
+// OAuthCallback is the Gin handler for GET /oauth/callback
+func (h *AlertHandler) OAuthCallback(c *gin.Context) {
+	ginHandler(h.oauthCallback)(c)
+}
+
+// GetAuthStatus is the Gin handler for GET /auth/status
+func (h *AlertHandler) GetAuthStatus(c *gin.Context) {
+	ginHandler(h.getAuthStatus)(c)
+}
@@ -0,0 +1,231 @@
+package zoomalert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GitHub webhook headers
+const (
+	GitHubEventHeader     = "X-GitHub-Event"
+	GitHubDeliveryHeader  = "X-GitHub-Delivery"
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+// githubRepository is the repository object of GitHub webhook payloads
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// githubCommit is the head_commit object of workflow runs and check suites
+type githubCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Author  struct {
+		Name string `json:"name"`
+	} `json:"author"`
+}
+
+// githubUser is the actor/creator/sender object of GitHub webhook payloads
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+// githubEvent holds the fields used from workflow_run, deployment_status and
+// check_suite payloads
+type githubEvent struct {
+	Action     string           `json:"action"`
+	Repository githubRepository `json:"repository"`
+	Sender     githubUser       `json:"sender"`
+
+	WorkflowRun *struct {
+		Name         string       `json:"name"`
+		DisplayTitle string       `json:"display_title"`
+		HeadBranch   string       `json:"head_branch"`
+		HeadSHA      string       `json:"head_sha"`
+		HTMLURL      string       `json:"html_url"`
+		Conclusion   string       `json:"conclusion"`
+		RunNumber    int          `json:"run_number"`
+		HeadCommit   githubCommit `json:"head_commit"`
+		Actor        githubUser   `json:"actor"`
+	} `json:"workflow_run"`
+
+	DeploymentStatus *struct {
+		State          string `json:"state"`
+		Environment    string `json:"environment"`
+		TargetURL      string `json:"target_url"`
+		LogURL         string `json:"log_url"`
+		EnvironmentURL string `json:"environment_url"`
+	} `json:"deployment_status"`
+	Deployment *struct {
+		Ref     string     `json:"ref"`
+		SHA     string     `json:"sha"`
+		Creator githubUser `json:"creator"`
+	} `json:"deployment"`
+
+	CheckSuite *struct {
+		HeadBranch string       `json:"head_branch"`
+		HeadSHA    string       `json:"head_sha"`
+		Conclusion string       `json:"conclusion"`
+		HeadCommit githubCommit `json:"head_commit"`
+		App        struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	} `json:"check_suite"`
+}
+
+// GitHubWebhook receives GitHub workflow_run, deployment_status and check_suite events
+func (h *AlertHandler) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.GitHub
+	if cfg == nil {
+		integrationNotConfigured(w, "GitHub")
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	if err := verifyGitHubSignature(cfg.Secret, r.Header.Get(GitHubSignatureHeader), body); err != nil {
+		writeJSON(w, http.StatusUnauthorized, jsonObject{"error": err.Error()})
+		return
+	}
+
+	eventType := r.Header.Get(GitHubEventHeader)
+	if eventType == "ping" {
+		writeJSON(w, http.StatusOK, jsonObject{"message": "pong"})
+		return
+	}
+
+	var payload githubEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid GitHub payload: " + err.Error()})
+		return
+	}
+
+	ev, ok := githubPipelineEvent(eventType, payload)
+	if !ok {
+		// Unsupported events and runs that haven't finished are acknowledged silently
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	dest, ok := cfg.route(ev)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	o := integrationSendOptions(r, dest)
+	o.source = "github"
+	if o.idempotencyKey == "" {
+		// Redeliveries keep their delivery ID
+		o.idempotencyKey = r.Header.Get(GitHubDeliveryHeader)
+	}
+	h.dispatch(w, r, dest.recipients(), renderPipelineEvent(ev), o)
+}
+
+// verifyGitHubSignature checks X-Hub-Signature-256 = "sha256=" + hex(HMAC-SHA256(secret, body))
+func verifyGitHubSignature(secret, signature string, body []byte) error {
+	if signature == "" {
+		return fmt.Errorf("missing %s header", GitHubSignatureHeader)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid GitHub signature")
+	}
+	return nil
+}
+
+// githubPipelineEvent normalizes a finished run, check suite or deployment. It
+// returns false for other events and for runs still in progress.
+func githubPipelineEvent(eventType string, p githubEvent) (pipelineEvent, bool) {
+	ev := pipelineEvent{
+		Provider:   "GitHub",
+		Repository: p.Repository.FullName,
+		RepoURL:    p.Repository.HTMLURL,
+	}
+
+	switch {
+	case eventType == "workflow_run" && p.WorkflowRun != nil:
+		run := p.WorkflowRun
+		if p.Action != "completed" {
+			return ev, false
+		}
+		ev.Kind = "workflow run"
+		ev.Name = fmt.Sprintf("%s #%d", run.Name, run.RunNumber)
+		ev.Status = run.Conclusion
+		ev.Branch = run.HeadBranch
+		ev.Commit = run.HeadSHA
+		ev.CommitText = run.HeadCommit.Message
+		if ev.CommitText == "" {
+			ev.CommitText = run.DisplayTitle
+		}
+		ev.Author = run.HeadCommit.Author.Name
+		if ev.Author == "" {
+			ev.Author = run.Actor.Login
+		}
+		ev.RunURL = run.HTMLURL
+
+	case eventType == "deployment_status" && p.DeploymentStatus != nil:
+		status := p.DeploymentStatus
+		switch status.State {
+		case "success", "failure", "error":
+		default:
+			// queued, pending, in_progress and inactive are not outcomes
+			return ev, false
+		}
+		ev.Kind = "deployment"
+		ev.Status = status.State
+		ev.Environment = status.Environment
+		ev.EnvironmentURL = status.EnvironmentURL
+		ev.RunURL = status.LogURL
+		if ev.RunURL == "" {
+			ev.RunURL = status.TargetURL
+		}
+		ev.Author = p.Sender.Login
+		if d := p.Deployment; d != nil {
+			ev.Branch = d.Ref
+			ev.Commit = d.SHA
+			if d.Creator.Login != "" {
+				ev.Author = d.Creator.Login
+			}
+		}
+
+	case eventType == "check_suite" && p.CheckSuite != nil:
+		suite := p.CheckSuite
+		if p.Action != "completed" {
+			return ev, false
+		}
+		ev.Kind = "check suite"
+		ev.Name = "Checks"
+		if suite.App.Name != "" {
+			ev.Name = suite.App.Name + " checks"
+		}
+		ev.Status = suite.Conclusion
+		ev.Branch = suite.HeadBranch
+		ev.Commit = suite.HeadSHA
+		ev.CommitText = suite.HeadCommit.Message
+		ev.Author = suite.HeadCommit.Author.Name
+		if ev.RepoURL != "" && ev.Commit != "" {
+			ev.RunURL = ev.RepoURL + "/commit/" + ev.Commit + "/checks"
+		}
+
+	default:
+		return ev, false
+	}
+
+	if ev.RepoURL != "" && ev.Commit != "" {
+		ev.CommitURL = ev.RepoURL + "/commit/" + ev.Commit
+	}
+	ev.Branch = strings.TrimPrefix(ev.Branch, "refs/heads/")
+	return ev, true
+}
@@ -0,0 +1,172 @@
+package zoomalert
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GitLab webhook headers
+const (
+	GitLabEventHeader = "X-Gitlab-Event"
+	GitLabUUIDHeader  = "X-Gitlab-Event-UUID"
+	GitLabTokenHeader = "X-Gitlab-Token"
+)
+
+// gitlabProject is the project object of GitLab webhook payloads
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+// gitlabUser is the user object of GitLab webhook payloads
+type gitlabUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// gitlabEvent holds the fields used from pipeline and deployment payloads
+type gitlabEvent struct {
+	ObjectKind string        `json:"object_kind"`
+	Project    gitlabProject `json:"project"`
+	User       gitlabUser    `json:"user"`
+
+	// Pipeline events
+	ObjectAttributes *struct {
+		ID     int    `json:"id"`
+		Name   string `json:"name"`
+		Ref    string `json:"ref"`
+		SHA    string `json:"sha"`
+		Status string `json:"status"`
+		URL    string `json:"url"`
+	} `json:"object_attributes"`
+	Commit *struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commit"`
+
+	// Deployment events
+	Status                 string `json:"status"`
+	Environment            string `json:"environment"`
+	EnvironmentExternalURL string `json:"environment_external_url"`
+	DeployableURL          string `json:"deployable_url"`
+	Ref                    string `json:"ref"`
+	ShortSHA               string `json:"short_sha"`
+	CommitURL              string `json:"commit_url"`
+	CommitTitle            string `json:"commit_title"`
+}
+
+// GitLabWebhook receives GitLab pipeline and deployment events
+func (h *AlertHandler) GitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.GitLab
+	if cfg == nil {
+		integrationNotConfigured(w, "GitLab")
+		return
+	}
+
+	token := r.Header.Get(GitLabTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Secret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, jsonObject{"error": "invalid " + GitLabTokenHeader})
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	var payload gitlabEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid GitLab payload: " + err.Error()})
+		return
+	}
+
+	ev, ok := gitlabPipelineEvent(payload)
+	if !ok {
+		// Other events and unfinished pipelines are acknowledged silently
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	dest, ok := cfg.route(ev)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	o := integrationSendOptions(r, dest)
+	o.source = "gitlab"
+	if o.idempotencyKey == "" {
+		o.idempotencyKey = r.Header.Get(GitLabUUIDHeader)
+	}
+	h.dispatch(w, r, dest.recipients(), renderPipelineEvent(ev), o)
+}
+
+// gitlabPipelineEvent normalizes a finished pipeline or deployment. It returns
+// false for other events and for pipelines still running.
+func gitlabPipelineEvent(p gitlabEvent) (pipelineEvent, bool) {
+	ev := pipelineEvent{
+		Provider:   "GitLab",
+		Repository: p.Project.PathWithNamespace,
+		RepoURL:    p.Project.WebURL,
+		Author:     p.User.Name,
+	}
+
+	switch p.ObjectKind {
+	case "pipeline":
+		attrs := p.ObjectAttributes
+		if attrs == nil || !gitlabFinished(attrs.Status) {
+			return ev, false
+		}
+		ev.Kind = "pipeline"
+		ev.Name = fmt.Sprintf("Pipeline #%d", attrs.ID)
+		if attrs.Name != "" {
+			ev.Name = fmt.Sprintf("%s #%d", attrs.Name, attrs.ID)
+		}
+		ev.Status = attrs.Status
+		ev.Branch = attrs.Ref
+		ev.Commit = attrs.SHA
+		ev.RunURL = attrs.URL
+		if ev.RunURL == "" && ev.RepoURL != "" {
+			ev.RunURL = fmt.Sprintf("%s/-/pipelines/%d", ev.RepoURL, attrs.ID)
+		}
+		if c := p.Commit; c != nil {
+			ev.CommitText = c.Message
+			ev.CommitURL = c.URL
+			if c.Author.Name != "" {
+				ev.Author = c.Author.Name
+			}
+		}
+
+	case "deployment":
+		if !gitlabFinished(p.Status) {
+			return ev, false
+		}
+		ev.Kind = "deployment"
+		ev.Status = p.Status
+		ev.Environment = p.Environment
+		ev.EnvironmentURL = p.EnvironmentExternalURL
+		ev.Branch = p.Ref
+		ev.Commit = p.ShortSHA
+		ev.CommitURL = p.CommitURL
+		ev.CommitText = p.CommitTitle
+		ev.RunURL = p.DeployableURL
+
+	default:
+		return ev, false
+	}
+	return ev, true
+}
+
+// gitlabFinished reports whether a pipeline or deployment status is final
+func gitlabFinished(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped":
+		return true
+	}
+	return false
+}
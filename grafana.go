@@ -0,0 +1,340 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GrafanaConfig routes Grafana notifications by contact point name
+type GrafanaConfig struct {
+	// Receivers maps Grafana contact point names (unified alerting) to destinations
+	Receivers map[string]Destination `json:"receivers"`
+	// Default receives legacy dashboard alerts and notifications for unlisted contact points
+	Default *Destination `json:"default,omitempty"`
+}
+
+// GrafanaPayload is Grafana's webhook payload. Unified alerting fills Alerts;
+// legacy dashboard alerting fills the rule fields and EvalMatches.
+type GrafanaPayload struct {
+	// Unified alerting
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	Alerts            []GrafanaAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+
+	// Shared
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+
+	// Legacy alerting
+	RuleName    string             `json:"ruleName"`
+	RuleURL     string             `json:"ruleUrl"`
+	ImageURL    string             `json:"imageUrl"`
+	EvalMatches []GrafanaEvalMatch `json:"evalMatches"`
+	Tags        map[string]string  `json:"tags"`
+	DashboardID int                `json:"dashboardId"`
+	PanelID     int                `json:"panelId"`
+}
+
+// GrafanaAlert is a single alert of a unified alerting notification
+type GrafanaAlert struct {
+	Status       string             `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+	GeneratorURL string             `json:"generatorURL"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+}
+
+// GrafanaEvalMatch is a metric value that triggered a legacy alert rule
+type GrafanaEvalMatch struct {
+	Metric string            `json:"metric"`
+	Value  *float64          `json:"value"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// unified reports whether the payload comes from Grafana unified alerting
+func (p *GrafanaPayload) unified() bool {
+	return len(p.Alerts) > 0
+}
+
+// destination returns where notifications for a contact point go
+func (c *GrafanaConfig) destination(receiver string) (Destination, bool) {
+	if dest, ok := c.Receivers[receiver]; ok && receiver != "" {
+		return dest, true
+	}
+	if c.Default != nil {
+		return *c.Default, true
+	}
+	return Destination{}, false
+}
+
+// validate checks every contact point destination
+func (c *GrafanaConfig) validate() error {
+	for name, dest := range c.Receivers {
+		if err := dest.validate(); err != nil {
+			return fmt.Errorf("receiver %q: %w", name, err)
+		}
+	}
+	if c.Default != nil {
+		if err := c.Default.validate(); err != nil {
+			return fmt.Errorf("default receiver: %w", err)
+		}
+	}
+	return nil
+}
+
+// GrafanaWebhook receives Grafana contact point notifications, both unified and legacy
+func (h *AlertHandler) GrafanaWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.Grafana
+	if cfg == nil {
+		integrationNotConfigured(w, "Grafana")
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	var payload GrafanaPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, AlertResponse{
+			Success: false,
+			Message: "Invalid Grafana payload",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var msg message
+	switch {
+	case payload.unified():
+		msg = renderGrafanaUnified(payload)
+	case payload.RuleName != "" || payload.State != "":
+		msg = renderGrafanaLegacy(payload)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	dest, ok := cfg.destination(payload.Receiver)
+	if !ok {
+		noDestination(w, fmt.Sprintf("contact point %q", payload.Receiver))
+		return
+	}
+
+	h.deliverIntegration(w, r, dest, msg)
+}
+
+// renderGrafanaUnified renders a unified alerting notification group
+func renderGrafanaUnified(p GrafanaPayload) message {
+	level := AlertLevelInfo
+	firing := 0
+	for _, alert := range p.Alerts {
+		if alert.Status != "resolved" {
+			firing++
+			level = maxLevel(level, levelFromSeverity(alert.Labels["severity"]))
+		}
+	}
+	// Grafana rules rarely carry a severity label; a firing alert is at least a warning
+	if firing > 0 && level == AlertLevelInfo {
+		level = AlertLevelWarning
+	}
+
+	title := p.Title
+	if title == "" {
+		title = fmt.Sprintf("[%s:%d] %s", strings.ToUpper(p.Status), len(p.Alerts), p.CommonLabels["alertname"])
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:    title,
+			Style:   ZoomStyle{Color: level.Color(), Bold: true},
+			SubHead: ZoomSubhead{Text: fmt.Sprintf("%s · %d firing", level, firing)},
+		},
+		Body: []any{},
+	}
+	if firing == 0 {
+		content.Head.Style.Color = resolvedColor
+		content.Head.SubHead.Text = "RESOLVED"
+		level = AlertLevelInfo
+	}
+	if p.Receiver != "" {
+		content.Head.SubHead.Text += " · contact point " + p.Receiver
+	}
+
+	if common := labelFields(p.CommonLabels, nil); len(common) > 0 {
+		content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: common})
+	}
+	if text := annotationText(p.CommonAnnotations); text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: text, Markdown: true})
+	}
+
+	for i, alert := range p.Alerts {
+		if i == maxIntegrationItems {
+			content.Body = append(content.Body, Message{
+				Type: "message",
+				Text: fmt.Sprintf("_…and %d more alerts_", len(p.Alerts)-maxIntegrationItems),
+			})
+			break
+		}
+		content.Body = append(content.Body, grafanaAlertBlocks(p, alert)...)
+	}
+
+	footer := "Grafana"
+	if p.TruncatedAlerts > 0 {
+		footer += fmt.Sprintf(" · %d alerts truncated", p.TruncatedAlerts)
+	}
+	content.Footer = ZoomFooter{Text: footer}
+
+	return message{content: content, level: level}
+}
+
+// grafanaAlertBlocks renders one alert of a unified alerting group
+func grafanaAlertBlocks(p GrafanaPayload, alert GrafanaAlert) []any {
+	name := alert.Labels["alertname"]
+	if name == "" {
+		name = "Alert"
+	}
+
+	text := alertHeadline(name, alert.Status, alert.StartsAt, alert.EndsAt)
+	if annotations := annotationText(withoutCommon(alert.Annotations, p.CommonAnnotations)); annotations != "" {
+		text += "\n" + annotations
+	}
+
+	blocks := []any{Message{Type: "message", Text: text, Markdown: true}}
+
+	fields := labelFields(alert.Labels, p.CommonLabels)
+	fields = append(fields, grafanaValueFields(alert.Values)...)
+	if len(fields) > 0 {
+		blocks = append(blocks, FieldsBlock{Type: "fields", Items: fields})
+	}
+
+	if alert.ImageURL != "" {
+		blocks = append(blocks, imageBlock(alert.ImageURL, alert.PanelURL, name))
+	}
+
+	var actions []Action
+	if alert.DashboardURL != "" {
+		actions = append(actions, LinkAction("Dashboard", alert.DashboardURL))
+	}
+	if alert.PanelURL != "" {
+		actions = append(actions, LinkAction("Panel", alert.PanelURL))
+	}
+	if alert.GeneratorURL != "" {
+		actions = append(actions, LinkAction("Rule", alert.GeneratorURL))
+	}
+	if alert.SilenceURL != "" && alert.Status != "resolved" {
+		actions = append(actions, LinkAction("Silence", alert.SilenceURL))
+	}
+	if len(actions) > 0 {
+		blocks = append(blocks, ActionsBlock{Type: "actions", Items: actions})
+	}
+	return blocks
+}
+
+// renderGrafanaLegacy renders a legacy dashboard alert notification
+func renderGrafanaLegacy(p GrafanaPayload) message {
+	level := AlertLevelInfo
+	color := ""
+	switch p.State {
+	case "alerting":
+		level = AlertLevelError
+		if severity := p.Tags["severity"]; severity != "" {
+			level = levelFromSeverity(severity)
+		}
+	case "no_data":
+		level = AlertLevelWarning
+	case "ok":
+		color = resolvedColor
+	}
+	if color == "" {
+		color = level.Color()
+	}
+
+	title := p.Title
+	if title == "" {
+		title = fmt.Sprintf("[%s] %s", p.State, p.RuleName)
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:    title,
+			Style:   ZoomStyle{Color: color, Bold: true},
+			SubHead: ZoomSubhead{Text: strings.ToUpper(strings.ReplaceAll(p.State, "_", " "))},
+		},
+		Body: []any{},
+	}
+
+	if p.Message != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: p.Message})
+	}
+
+	var fields []Field
+	for i, match := range p.EvalMatches {
+		if i == maxIntegrationItems {
+			fields = append(fields, Field{Key: "…", Value: fmt.Sprintf("%d more", len(p.EvalMatches)-maxIntegrationItems)})
+			break
+		}
+		value := "null"
+		if match.Value != nil {
+			value = formatMetricValue(*match.Value)
+		}
+		fields = append(fields, Field{Key: match.Metric, Value: value})
+	}
+	for _, key := range sortedKeys(p.Tags) {
+		fields = append(fields, Field{Key: key, Value: p.Tags[key]})
+	}
+	if len(fields) > 0 {
+		content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: fields})
+	}
+
+	if p.ImageURL != "" {
+		content.Body = append(content.Body, imageBlock(p.ImageURL, p.RuleURL, p.RuleName))
+	}
+	if p.RuleURL != "" {
+		content.Body = append(content.Body, ActionsBlock{
+			Type:  "actions",
+			Items: []Action{LinkAction("View in Grafana", p.RuleURL)},
+		})
+	}
+
+	content.Footer = ZoomFooter{Text: "Grafana"}
+	return message{content: content, level: level}
+}
+
+// grafanaValueFields renders the query values that triggered an alert
+func grafanaValueFields(values map[string]float64) []Field {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, Field{Key: key, Value: formatMetricValue(values[key])})
+	}
+	return fields
+}
+
+// formatMetricValue prints a metric value without needless precision
+func formatMetricValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
@@ -1,128 +1,479 @@
 package zoomalert
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
-
-	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
 )
 
 // AlertHandler handles HTTP requests for alert operations
 type AlertHandler struct {
-	zoomService *ZoomService
+	tenants      *tenantRegistry
+	dispatcher   *dispatcher
+	integrations IntegrationsConfig
+	sns          *snsVerifier
+	webhooks     map[string]*webhookMapper
+}
+
+// RecipientFields selects the recipients of an alert request: one or more emails
+// and/or channel JIDs
+type RecipientFields struct {
+	Email    string   `json:"email,omitempty"`
+	Emails   []string `json:"emails,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+// recipients flattens the recipient fields
+func (f RecipientFields) recipients() []Recipient {
+	var tos []Recipient
+	for _, email := range append([]string{f.Email}, f.Emails...) {
+		if email != "" {
+			tos = append(tos, Recipient{Email: email})
+		}
+	}
+	for _, channel := range append([]string{f.Channel}, f.Channels...) {
+		if channel != "" {
+			tos = append(tos, Recipient{ChannelJID: channel})
+		}
+	}
+	return tos
 }
 
-// AlertRequest represents the request payload for sending alerts
+// AlertRequest represents the request payload for sending alerts. Its fields are
+// listed rather than embedding RecipientFields so existing composite literals
+// (AlertRequest{Email: ..., Message: ...}) keep compiling.
 type AlertRequest struct {
-	Email   string `json:"email" binding:"required"`
-	Message string `json:"message" binding:"required"`
+	// Email, Emails, Channel and Channels select the recipients
+	Email    string   `json:"email,omitempty"`
+	Emails   []string `json:"emails,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+	Message  string   `json:"message"`
+	// Bot optionally selects the sending chatbot by name
+	Bot string `json:"bot,omitempty"`
+}
+
+// recipients flattens the recipient fields
+func (req AlertRequest) recipients() []Recipient {
+	return RecipientFields{Email: req.Email, Emails: req.Emails, Channel: req.Channel, Channels: req.Channels}.recipients()
+}
+
+// RichAlertRequest is the payload for sending pre-built chatbot content
+type RichAlertRequest struct {
+	RecipientFields
+	Bot     string      `json:"bot,omitempty"`
+	Content ZoomContent `json:"content"`
+}
+
+// SeverityAlertRequest is the payload for sending a severity-tagged alert
+type SeverityAlertRequest struct {
+	RecipientFields
+	Bot string `json:"bot,omitempty"`
+	Alert
+}
+
+// TemplatedAlertRequest is the payload for sending an alert rendered from a template
+type TemplatedAlertRequest struct {
+	RecipientFields
+	Bot      string         `json:"bot,omitempty"`
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data"`
 }
 
 // AlertResponse represents the response from alert operations
 type AlertResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	MessageID string `json:"message_id,omitempty"`
+	// Results lists each recipient of a multi-recipient request
+	Results []SendResult `json:"results,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	// Rule names the violated policy rule on 403 responses
+	Rule string `json:"rule,omitempty"`
+	// JobID and Status describe a send queued with ?async=true
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status,omitempty"`
 }
 
-// NewAlertHandler creates a new AlertHandler
+// NewAlertHandler creates a new AlertHandler for a single Zoom account
 func NewAlertHandler(zoomService *ZoomService) *AlertHandler {
+	tenants := newTenantRegistry(&Tenant{
+		id:           DefaultTenantID,
+		config:       zoomService.oauthService.GetConfig(),
+		oauthService: zoomService.oauthService,
+		zoomService:  zoomService,
+	})
+	return newDispatchAlertHandler(&dispatcher{
+		tenants:   tenants,
+		templates: NewTemplateRegistry(),
+		logger:    zoomService.logger,
+	})
+}
+
+// newDispatchAlertHandler creates an AlertHandler that sends through d and serves
+// every tenant in its registry
+func newDispatchAlertHandler(d *dispatcher) *AlertHandler {
 	return &AlertHandler{
-		zoomService: zoomService,
+		tenants:    d.tenants,
+		dispatcher: d,
 	}
 }
 
-// SendAlert sends alert using the best available authorization method
-func (h *AlertHandler) SendAlert(c *gin.Context) {
-	if !h.zoomService.IsUserAuthorized() {
-		c.JSON(http.StatusUnauthorized, AlertResponse{
-			Success: false,
-			Message: "User is not authorized",
+// jsonObject is an ad-hoc JSON response body
+type jsonObject map[string]any
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write JSON response:", "error", err)
+	}
+}
+
+// requestedTenant returns the tenant id from the path or the X-Zoom-Tenant header
+func requestedTenant(r *http.Request) string {
+	if id := r.PathValue("tenant"); id != "" {
+		return id
+	}
+	return r.Header.Get(TenantHeader)
+}
+
+// tenantService resolves the ZoomService for the request's tenant, writing a 404 if unknown
+func (h *AlertHandler) tenantService(w http.ResponseWriter, r *http.Request) (*ZoomService, bool) {
+	tenant, err := h.tenants.get(requestedTenant(r))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, jsonObject{
+			"error": err.Error(),
 		})
-		return
+		return nil, false
 	}
+	return tenant.zoomService, true
+}
 
-	var req AlertRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, AlertResponse{
+// requestSendOptions builds the send options for a request's tenant, bot and client
+func requestSendOptions(r *http.Request, bot string) sendOptions {
+	client, _ := ClientFromContext(r.Context())
+	source := client.ID
+	if source == "" {
+		source = "addr:" + remoteHost(r)
+	}
+	return sendOptions{
+		tenant:         requestedTenant(r),
+		bot:            bot,
+		client:         client,
+		source:         source,
+		idempotencyKey: r.Header.Get(IdempotencyKeyHeader),
+	}
+}
+
+// remoteHost returns the client address of a request without its port
+func remoteHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// bindAlertRequest decodes the JSON body into req, writing a 400 on failure and a
+// 413 when the body exceeds the same 1 MiB cap as webhook bodies
+func bindAlertRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	body := http.MaxBytesReader(w, r.Body, maxSignedBodyBytes)
+	if err := json.NewDecoder(body).Decode(req); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, AlertResponse{
 			Success: false,
 			Message: "Invalid request format",
 			Error:   err.Error(),
 		})
-		return
+		return false
 	}
+	return true
+}
 
-	// Validate email and message
-	if req.Email == "" {
-		c.JSON(http.StatusBadRequest, AlertResponse{
+// requireField writes a 400 naming the missing field when value is empty
+func requireField(w http.ResponseWriter, name, value string) bool {
+	if value == "" {
+		writeJSON(w, http.StatusBadRequest, AlertResponse{
 			Success: false,
-			Message: "Email is required",
+			Message: name + " is required",
 		})
+		return false
+	}
+	return true
+}
+
+// writeSendResults writes the outcome of a send
+func writeSendResults(w http.ResponseWriter, results []SendResult, err error) {
+	if len(results) > 0 && results[0].Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	var limited *RateLimitError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+	}
+
+	status, resp := sendResponse(results, err)
+	writeJSON(w, status, resp)
+}
+
+// sendResponse builds the status and body reporting the outcome of a send
+func sendResponse(results []SendResult, err error) (int, AlertResponse) {
+	resp := AlertResponse{
+		Success: err == nil,
+		Message: "Alert sent successfully",
+	}
+	if len(results) == 1 {
+		resp.MessageID = results[0].MessageID
+	} else {
+		resp.Results = results
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status = sendErrorStatus(err)
+		if status >= http.StatusInternalServerError {
+			slog.Error("Failed to send alert:", "error", err)
+		}
+		resp.Message = "Failed to send alert"
+		resp.Error = err.Error()
+		var violation *PolicyViolation
+		if errors.As(err, &violation) {
+			resp.Message = "Forbidden by policy"
+			resp.Rule = violation.Rule
+		}
+		if errors.Is(err, ErrRateLimited) {
+			resp.Message = "Rate limit exceeded"
+		}
+	}
+	return status, resp
+}
+
+// jobResponse reports a queued asynchronous send
+func jobResponse(job *Job) (int, AlertResponse) {
+	return http.StatusAccepted, AlertResponse{
+		Success: true,
+		Message: "Alert queued",
+		JobID:   job.ID,
+		Status:  job.Status,
+	}
+}
+
+// sendErrorStatus maps send pipeline errors to HTTP status codes
+func sendErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidMessage), errors.Is(err, ErrUnknownBot), errors.Is(err, ErrUnknownTemplate):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrPolicyViolation):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrIdempotencyPending):
+		return http.StatusConflict
+	case errors.Is(err, ErrIdempotencyMismatch), errors.Is(err, ErrNoDestination):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrQueueClosed):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrUnknownTenant):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUserNotAuthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// dispatch sends a message now, or queues it when the request asks for ?async=true
+func (h *AlertHandler) dispatch(w http.ResponseWriter, r *http.Request, tos []Recipient, msg message, o sendOptions) {
+	results, job, err := h.submit(r, tos, msg, o)
+	if job != nil {
+		status, resp := jobResponse(job)
+		writeJSON(w, status, resp)
 		return
 	}
+	writeSendResults(w, results, err)
+}
 
-	if req.Message == "" {
-		c.JSON(http.StatusBadRequest, AlertResponse{
-			Success: false,
-			Message: "Message is required",
+// submit delivers a message now, or queues it and returns its job when the
+// request asks for ?async=true
+func (h *AlertHandler) submit(r *http.Request, tos []Recipient, msg message, o sendOptions) ([]SendResult, *Job, error) {
+	if !parseBool(r.URL.Query().Get("async")) {
+		results, err := h.dispatcher.deliver(tos, msg, o)
+		return results, nil, err
+	}
+
+	if h.dispatcher.jobs == nil {
+		return nil, nil, fmt.Errorf("%w: asynchronous sends are not enabled", ErrInvalidMessage)
+	}
+
+	callbackURL := r.URL.Query().Get("callback_url")
+	if err := validateCallbackURL(callbackURL); err != nil {
+		return nil, nil, err
+	}
+
+	dl, err := h.dispatcher.prepare(tos, msg, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dl.replay != nil {
+		results, err := dl.replay.replay()
+		return results, nil, err
+	}
+
+	job, err := h.dispatcher.jobs.submit(dl, callbackURL)
+	if err != nil {
+		h.dispatcher.abandon(dl)
+		return nil, nil, err
+	}
+	return nil, &job, nil
+}
+
+// GetJob reports the status of an asynchronous send
+func (h *AlertHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	if h.dispatcher.jobs == nil {
+		writeJSON(w, http.StatusNotFound, jsonObject{
+			"error": "asynchronous sends are not enabled",
 		})
 		return
 	}
 
-	err := h.zoomService.PostTextByEmail(req.Email, req.Message)
-	if err != nil {
-		slog.Error("Failed to send alert with authorization:", "error", err)
-		c.JSON(http.StatusInternalServerError, AlertResponse{
-			Success: false,
-			Message: "Failed to send alert",
-			Error:   err.Error(),
+	job, ok := h.dispatcher.jobs.get(r.PathValue("id"), requestSendOptions(r, "").source)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, jsonObject{
+			"error": "job not found",
 		})
 		return
 	}
+	writeJSON(w, http.StatusOK, job)
+}
 
-	c.JSON(http.StatusOK, AlertResponse{
-		Success: true,
-		Message: "Alert sent successfully",
-	})
+// sendAlert sends a plain text alert to users or channels
+func (h *AlertHandler) sendAlert(w http.ResponseWriter, r *http.Request) {
+	var req AlertRequest
+	if !bindAlertRequest(w, r, &req) || !requireField(w, "message", req.Message) {
+		return
+	}
+
+	msg := message{content: ZoomContent{Head: ZoomHead{Text: req.Message}}}
+	h.dispatch(w, r, req.recipients(), msg, requestSendOptions(r, req.Bot))
+}
+
+// SendRichAlert sends caller-built chatbot content to users or channels
+func (h *AlertHandler) SendRichAlert(w http.ResponseWriter, r *http.Request) {
+	var req RichAlertRequest
+	if !bindAlertRequest(w, r, &req) || !requireField(w, "content.head.text", req.Content.Head.Text) {
+		return
+	}
+
+	h.dispatch(w, r, req.recipients(), message{content: req.Content}, requestSendOptions(r, req.Bot))
+}
+
+// SendSeverityAlert sends a severity-colored alert to users or channels
+func (h *AlertHandler) SendSeverityAlert(w http.ResponseWriter, r *http.Request) {
+	var req SeverityAlertRequest
+	if !bindAlertRequest(w, r, &req) {
+		return
+	}
+
+	if level, err := ParseAlertLevel(string(req.Level)); err == nil {
+		req.Level = level
+	}
+
+	msg, err := alertMessage(req.Alert)
+	if err != nil {
+		writeSendResults(w, nil, err)
+		return
+	}
+	h.dispatch(w, r, req.recipients(), msg, requestSendOptions(r, req.Bot))
 }
 
-// HealthCheck returns the health status of the service
-func (h *AlertHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+// SendTemplatedAlert renders a named template with the request data and sends it
+func (h *AlertHandler) SendTemplatedAlert(w http.ResponseWriter, r *http.Request) {
+	var req TemplatedAlertRequest
+	if !bindAlertRequest(w, r, &req) || !requireField(w, "template", req.Template) {
+		return
+	}
+
+	o := requestSendOptions(r, req.Bot)
+	msg, err := h.dispatcher.templateMessage(req.Template, req.Data, &o)
+	if err != nil {
+		writeSendResults(w, nil, err)
+		return
+	}
+	h.dispatch(w, r, req.recipients(), msg, o)
+}
+
+// healthCheck returns the health status of the service
+func (h *AlertHandler) healthCheck(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jsonObject{
 		"status":  "healthy",
 		"service": "zoom-alert-service",
 	})
 }
 
-// OAuthAuthorize initiates the OAuth authorization flow
-func (h *AlertHandler) OAuthAuthorize(c *gin.Context) {
+// oauthAuthorize initiates the OAuth authorization flow
+func (h *AlertHandler) oauthAuthorize(w http.ResponseWriter, r *http.Request) {
+	zoomService, ok := h.tenantService(w, r)
+	if !ok {
+		return
+	}
+
 	// Generate a secure state parameter for CSRF protection
-	state, err := h.zoomService.generateOAuthState()
+	state, err := zoomService.generateOAuthState(r.URL.Query().Get("return_to"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		writeJSON(w, http.StatusBadRequest, jsonObject{
 			"error": "Failed to generate OAuth state: " + err.Error(),
 		})
 		return
 	}
 
 	// Get the authorization URL
-	authURL := h.zoomService.GetAuthorizationURL(state)
+	authURL := zoomService.GetAuthorizationURL(state)
 
 	// Return both the URL and state for the frontend
-	c.JSON(http.StatusOK, gin.H{
+	writeJSON(w, http.StatusOK, jsonObject{
 		"authorization_url": authURL,
 		"state":             state,
 		"message":           "Please visit the authorization URL to complete OAuth flow",
 	})
 }
 
-// OAuthCallback handles the OAuth callback
-func (h *AlertHandler) OAuthCallback(c *gin.Context) {
+// OAuthStart redirects the browser straight to Zoom's consent screen
+func (h *AlertHandler) OAuthStart(w http.ResponseWriter, r *http.Request) {
+	zoomService, ok := h.tenantService(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := zoomService.generateOAuthState(r.URL.Query().Get("return_to"))
+	if err != nil {
+		h.oauthErrorPage(w, http.StatusBadRequest, "Could not start authorization.", err.Error())
+		return
+	}
+
+	http.Redirect(w, r, zoomService.GetAuthorizationURL(state), http.StatusFound)
+}
+
+// oauthCallback handles the OAuth callback. Browsers get an HTML page (or a redirect
+// to the return_to URL); API clients get JSON.
+func (h *AlertHandler) oauthCallback(w http.ResponseWriter, r *http.Request) {
 	// Extract parameters
-	code := c.Query("code")
-	state := c.Query("state")
-	errorParam := c.Query("error")
-	errorDescription := c.Query("error_description")
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	errorParam := r.URL.Query().Get("error")
+	errorDescription := r.URL.Query().Get("error_description")
+	browser := wantsHTML(r)
 
 	// Handle OAuth errors
 	if errorParam != "" {
@@ -131,7 +482,11 @@ func (h *AlertHandler) OAuthCallback(c *gin.Context) {
 			errorMsg += " (" + errorDescription + ")"
 		}
 
-		c.JSON(http.StatusBadRequest, gin.H{
+		if browser {
+			h.oauthErrorPage(w, http.StatusBadRequest, "Zoom did not grant authorization.", errorMsg)
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, jsonObject{
 			"error":   errorParam,
 			"message": errorMsg,
 		})
@@ -141,48 +496,210 @@ func (h *AlertHandler) OAuthCallback(c *gin.Context) {
 	// Validate required parameters
 	if code == "" {
 		errorMsg := "Missing authorization code in callback"
-		c.JSON(http.StatusBadRequest, gin.H{
+		if browser {
+			h.oauthErrorPage(w, http.StatusBadRequest, "The callback is missing its authorization code.", errorMsg)
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, jsonObject{
 			"error": errorMsg,
 		})
 		return
 	}
 
+	// Resolve the tenant from the request, or from the tenant that issued the state
+	var zoomService *ZoomService
+	if requestedTenant(r) != "" {
+		var ok bool
+		if zoomService, ok = h.tenantService(w, r); !ok {
+			return
+		}
+	} else {
+		zoomService = h.tenants.forState(state).zoomService
+	}
+
 	// Validate state parameter for CSRF protection
-	if err := h.zoomService.validateOAuthState(state); err != nil {
+	stateInfo, err := zoomService.consumeOAuthState(state)
+	if err != nil {
 		errorMsg := "Invalid or expired state parameter: " + err.Error()
-		c.JSON(http.StatusBadRequest, gin.H{
+		if browser {
+			h.oauthErrorPage(w, http.StatusBadRequest, "This authorization link is invalid or has expired.", errorMsg)
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, jsonObject{
 			"error": errorMsg,
 		})
 		return
 	}
 
 	// Exchange code for token
-	if err := h.zoomService.exchangeCodeForToken(code); err != nil {
+	if err := zoomService.exchangeCodeForToken(code, stateInfo.CodeVerifier); err != nil {
 		errorMsg := "Failed to exchange code for token: " + err.Error()
-		c.JSON(http.StatusInternalServerError, gin.H{
+		if browser {
+			h.oauthErrorPage(w, http.StatusBadGateway, "Zoom rejected the authorization code.", errorMsg)
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, jsonObject{
 			"error": errorMsg,
 		})
 		return
 	}
 
 	// Success
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Authorization successful",
-		"status":  "authorized",
+	if browser {
+		if stateInfo.ReturnTo != "" {
+			http.Redirect(w, r, stateInfo.ReturnTo, http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := renderOAuthSuccess(w, oauthSuccessPage{Status: zoomService.AuthStatus()}); err != nil {
+			slog.Error("Failed to render OAuth success page:", "error", err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonObject{
+		"message":   "Authorization successful",
+		"status":    "authorized",
+		"return_to": stateInfo.ReturnTo,
 	})
 }
 
-// GetAuthStatus returns the current authorization status
-func (h *AlertHandler) GetAuthStatus(c *gin.Context) {
-	isAuthorized := h.zoomService.IsUserAuthorized()
+// oauthErrorPage renders the HTML failure page with a link to restart the flow
+func (h *AlertHandler) oauthErrorPage(w http.ResponseWriter, status int, message, detail string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := renderOAuthError(w, oauthErrorPage{
+		Message:  message,
+		Detail:   detail,
+		RetryURL: "start",
+	}); err != nil {
+		slog.Error("Failed to render OAuth error page:", "error", err)
+	}
+}
+
+// wantsHTML reports whether the client prefers an HTML response (i.e. a browser)
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// getAuthStatus returns the current authorization status
+func (h *AlertHandler) getAuthStatus(w http.ResponseWriter, r *http.Request) {
+	zoomService, ok := h.tenantService(w, r)
+	if !ok {
+		return
+	}
+
+	status := zoomService.AuthStatus()
 
-	c.JSON(http.StatusOK, gin.H{
-		"user_authorized": isAuthorized,
+	writeJSON(w, http.StatusOK, jsonObject{
+		"user_authorized": status.UserAuthorized,
 		"message": func() string {
-			if isAuthorized {
+			if status.UserAuthorized && len(status.MissingScopes) > 0 {
+				return "User authorization available but required scopes are missing"
+			}
+			if status.UserAuthorized {
 				return "User authorization available - full user lookup enabled"
 			}
 			return "Only server-to-server authorization available - limited functionality"
 		}(),
+		"authorized_by":            status.AuthorizedBy,
+		"authorized_at":            status.AuthorizedAt,
+		"granted_scopes":           status.GrantedScopes,
+		"required_scopes":          status.RequiredScopes,
+		"missing_scopes":           status.MissingScopes,
+		"access_token_expires_at":  status.AccessTokenExpiresAt,
+		"refresh_token_expires_at": status.RefreshTokenExpiresAt,
+	})
+}
+
+// OAuthRevoke revokes the user authorization and clears stored tokens
+func (h *AlertHandler) OAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	zoomService, ok := h.tenantService(w, r)
+	if !ok {
+		return
+	}
+
+	if err := zoomService.revokeAuthorization(); err != nil {
+		slog.Error("Failed to revoke authorization:", "error", err)
+		writeJSON(w, http.StatusBadGateway, jsonObject{
+			"error":  "Failed to revoke authorization: " + err.Error(),
+			"status": "unauthorized",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonObject{
+		"message": "Authorization revoked",
+		"status":  "unauthorized",
 	})
 }
+
+// ZoomEvents handles Zoom webhook event notifications (URL validation and app deauthorization)
+func (h *AlertHandler) ZoomEvents(w http.ResponseWriter, r *http.Request) {
+	zoomService, ok := h.tenantService(w, r)
+	if !ok {
+		return
+	}
+
+	secret := zoomService.oauthService.GetConfig().ZoomWebhookSecretToken
+	if secret == "" {
+		writeJSON(w, http.StatusServiceUnavailable, jsonObject{
+			"error": "Zoom event webhook is not configured",
+		})
+		return
+	}
+
+	// The route is public, so cap the body before checking its signature
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := verifyZoomEventSignature(secret,
+		r.Header.Get("x-zm-request-timestamp"),
+		r.Header.Get("x-zm-signature"),
+		body); err != nil {
+		writeJSON(w, http.StatusUnauthorized, jsonObject{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var event ZoomEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{
+			"error": "Invalid event payload: " + err.Error(),
+		})
+		return
+	}
+
+	switch event.Event {
+	case zoomEventURLValidation:
+		resp, err := answerURLValidation(secret, event.Payload)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, jsonObject{
+				"error": err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case zoomEventAppDeauthorized:
+		if err := zoomService.handleDeauthorization(event.Payload); err != nil {
+			slog.Error("Failed to handle deauthorization:", "error", err)
+			writeJSON(w, http.StatusInternalServerError, jsonObject{
+				"error": err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, jsonObject{
+			"message": "Deauthorization processed",
+			"status":  "unauthorized",
+		})
+	default:
+		slog.Debug("Ignoring unsupported Zoom event", "event", event.Event)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
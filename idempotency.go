@@ -0,0 +1,269 @@
+package zoomalert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the idempotency key of an alert request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency store types selectable via Config.IdempotencyStore
+const (
+	IdempotencyStoreMemory = "memory"
+	IdempotencyStoreFile   = "file"
+)
+
+// defaultIdempotencyWindow is how long a completed send is remembered
+const defaultIdempotencyWindow = 24 * time.Hour
+
+// idempotencyPendingTTL bounds how long an unfinished send holds its key, so a
+// crash mid-send doesn't block retries for the whole window
+const idempotencyPendingTTL = 5 * time.Minute
+
+// Errors returned for idempotent sends, usable with errors.Is
+var (
+	ErrIdempotencyPending  = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used for a different request")
+)
+
+// IdempotencyRecord is what a store remembers about an idempotency key
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request the key was first used for
+	Fingerprint string `json:"fingerprint"`
+	// Pending is true while the first request is still sending
+	Pending   bool         `json:"pending,omitempty"`
+	Results   []SendResult `json:"results,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// IdempotencyStore remembers sends by idempotency key. Implementations backed by
+// shared storage deduplicate across replicas.
+type IdempotencyStore interface {
+	// Reserve claims key with a pending record, or returns the existing record
+	// if the key is already known (claimed is then false)
+	Reserve(key string, record IdempotencyRecord) (existing IdempotencyRecord, claimed bool, err error)
+	// Complete replaces the pending record with the final one
+	Complete(key string, record IdempotencyRecord) error
+	// Release forgets a key so the request can be retried
+	Release(key string) error
+}
+
+// MemoryIdempotencyStore is the default process-local IdempotencyStore
+type MemoryIdempotencyStore struct {
+	records map[string]IdempotencyRecord
+	mutex   sync.Mutex
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]IdempotencyRecord),
+	}
+}
+
+// Reserve claims key unless an unexpired record exists
+func (s *MemoryIdempotencyStore) Reserve(key string, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanupExpired()
+	if existing, exists := s.records[key]; exists {
+		return existing, false, nil
+	}
+	s.records[key] = record
+	return IdempotencyRecord{}, true, nil
+}
+
+// Complete stores the final record for key
+func (s *MemoryIdempotencyStore) Complete(key string, record IdempotencyRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[key] = record
+	return nil
+}
+
+// Release removes key
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// cleanupExpired removes expired records (must be called with mutex held)
+func (s *MemoryIdempotencyStore) cleanupExpired() {
+	now := time.Now()
+	for key, record := range s.records {
+		if now.After(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// FileIdempotencyStore is a MemoryIdempotencyStore persisted to a JSON file, so
+// duplicates are still caught after a restart
+type FileIdempotencyStore struct {
+	memory *MemoryIdempotencyStore
+	path   string
+	// fileMutex serializes writes of the file
+	fileMutex sync.Mutex
+}
+
+// NewFileIdempotencyStore creates a store persisted at path, loading any existing records
+func NewFileIdempotencyStore(path string) (*FileIdempotencyStore, error) {
+	s := &FileIdempotencyStore{
+		memory: NewMemoryIdempotencyStore(),
+		path:   path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read idempotency file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.memory.records); err != nil {
+			return nil, fmt.Errorf("failed to parse idempotency file: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Reserve claims key and persists the pending record
+func (s *FileIdempotencyStore) Reserve(key string, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	existing, claimed, err := s.memory.Reserve(key, record)
+	if err != nil || !claimed {
+		return existing, claimed, err
+	}
+	return existing, claimed, s.save()
+}
+
+// Complete stores and persists the final record
+func (s *FileIdempotencyStore) Complete(key string, record IdempotencyRecord) error {
+	if err := s.memory.Complete(key, record); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Release removes key and persists the change
+func (s *FileIdempotencyStore) Release(key string) error {
+	if err := s.memory.Release(key); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// save writes all records to the file, replacing it atomically
+func (s *FileIdempotencyStore) save() error {
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+
+	s.memory.mutex.Lock()
+	data, err := json.Marshal(s.memory.records)
+	s.memory.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency records: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create idempotency directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace idempotency file: %w", err)
+	}
+	return nil
+}
+
+// idempotency applies an IdempotencyStore to the send pipeline
+type idempotency struct {
+	store  IdempotencyStore
+	window time.Duration
+}
+
+// begin claims key for a request. It returns the remembered record when the key
+// was already used for the same request and is complete.
+func (i *idempotency) begin(key, fingerprint string) (*IdempotencyRecord, error) {
+	existing, claimed, err := i.store.Reserve(key, IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Pending:     true,
+		ExpiresAt:   time.Now().Add(min(i.window, idempotencyPendingTTL)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("idempotency store: %w", err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyMismatch
+	}
+	if existing.Pending {
+		return nil, ErrIdempotencyPending
+	}
+	return &existing, nil
+}
+
+// finish remembers a send for the window. Sends that reached no recipient are
+// forgotten so that a retry sends again.
+func (i *idempotency) finish(key, fingerprint string, results []SendResult, sendErr error) error {
+	delivered := false
+	for _, result := range results {
+		if result.Error == "" {
+			delivered = true
+		}
+	}
+	if !delivered {
+		return i.store.Release(key)
+	}
+
+	record := IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Results:     results,
+		ExpiresAt:   time.Now().Add(i.window),
+	}
+	if sendErr != nil {
+		record.Error = sendErr.Error()
+	}
+	return i.store.Complete(key, record)
+}
+
+// replay turns a remembered record back into deliver's return values
+func (r *IdempotencyRecord) replay() ([]SendResult, error) {
+	results := make([]SendResult, len(r.Results))
+	for i, result := range r.Results {
+		result.Replayed = true
+		results[i] = result
+	}
+	if r.Error != "" {
+		return results, errors.New(r.Error)
+	}
+	return results, nil
+}
+
+// requestFingerprint hashes what was sent to whom so a reused key can be detected
+func requestFingerprint(tenant string, tos []Recipient, msg message, o sendOptions) string {
+	data, _ := json.Marshal(struct {
+		Tenant     string      `json:"tenant"`
+		Bot        string      `json:"bot"`
+		Recipients []Recipient `json:"recipients"`
+		Content    ZoomContent `json:"content"`
+	}{tenant, o.bot, tos, msg.content})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,153 @@
+package zoomalert
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdempotencySourceScoping(t *testing.T) {
+	d := newTestModule(t).dispatcher
+	tos := []Recipient{{ChannelJID: "sre@conference.xmpp.zoom.us"}}
+	msg := message{content: Alert{Title: "Deploy finished"}.Content(), level: AlertLevelInfo}
+
+	first, err := d.prepare(tos, msg, sendOptions{source: "ci", idempotencyKey: "deploy-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.abandon(first)
+
+	// The same key from the same source is the same send
+	if _, err := d.prepare(tos, msg, sendOptions{source: "ci", idempotencyKey: "deploy-42"}); !errors.Is(err, ErrIdempotencyPending) {
+		t.Fatalf("repeated key error = %v, want %v", err, ErrIdempotencyPending)
+	}
+
+	// Another source may use the same key without colliding
+	other, err := d.prepare(tos, msg, sendOptions{source: "monitoring", idempotencyKey: "deploy-42"})
+	if err != nil {
+		t.Fatalf("key of another source rejected: %v", err)
+	}
+	defer d.abandon(other)
+	if other.idempotencyKey == first.idempotencyKey {
+		t.Fatalf("both sources share the stored key %q", first.idempotencyKey)
+	}
+}
+
+func TestIdempotencyPendingExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	i := &idempotency{store: store, window: time.Hour}
+
+	if _, err := i.begin("k", "fp"); err != nil {
+		t.Fatal(err)
+	}
+	pending := store.records["k"]
+	if !pending.Pending || pending.ExpiresAt.After(time.Now().Add(idempotencyPendingTTL)) {
+		t.Fatalf("pending record %+v should expire within %s", pending, idempotencyPendingTTL)
+	}
+	if _, err := i.begin("k", "fp"); !errors.Is(err, ErrIdempotencyPending) {
+		t.Fatalf("error = %v, want %v", err, ErrIdempotencyPending)
+	}
+
+	// A send that never finished (a crash) stops blocking retries once its reservation expires
+	pending.ExpiresAt = time.Now().Add(-time.Second)
+	store.records["k"] = pending
+	if record, err := i.begin("k", "fp"); err != nil || record != nil {
+		t.Fatalf("begin after expiry = %+v, %v, want a fresh claim", record, err)
+	}
+
+	// A window shorter than the pending TTL bounds the reservation too
+	short := &idempotency{store: store, window: time.Minute}
+	if _, err := short.begin("short", "fp"); err != nil {
+		t.Fatal(err)
+	}
+	if expires := store.records["short"].ExpiresAt; expires.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("pending record expires at %s, after the window", expires)
+	}
+}
+
+func TestIdempotencyFinish(t *testing.T) {
+	to := Recipient{ChannelJID: "sre@conference.xmpp.zoom.us"}
+	other := Recipient{Email: "oncall@example.com"}
+
+	t.Run("failed delivery releases the key", func(t *testing.T) {
+		i := &idempotency{store: NewMemoryIdempotencyStore(), window: time.Hour}
+		if _, err := i.begin("k", "fp"); err != nil {
+			t.Fatal(err)
+		}
+		sendErr := errors.New("zoom unavailable")
+		if err := i.finish("k", "fp", []SendResult{{Recipient: to, Error: sendErr.Error()}}, sendErr); err != nil {
+			t.Fatal(err)
+		}
+		if record, err := i.begin("k", "fp"); err != nil || record != nil {
+			t.Fatalf("retry = %+v, %v, want a fresh claim", record, err)
+		}
+	})
+
+	t.Run("partial delivery is remembered", func(t *testing.T) {
+		i := &idempotency{store: NewMemoryIdempotencyStore(), window: time.Hour}
+		if _, err := i.begin("k", "fp"); err != nil {
+			t.Fatal(err)
+		}
+		sendErr := errors.New("user not found")
+		results := []SendResult{{Recipient: to, MessageID: "m-1"}, {Recipient: other, Error: sendErr.Error()}}
+		if err := i.finish("k", "fp", results, sendErr); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := i.begin("k", "fp")
+		if err != nil || record == nil {
+			t.Fatalf("retry = %+v, %v, want the remembered send", record, err)
+		}
+		replayed, err := record.replay()
+		if err == nil || err.Error() != sendErr.Error() {
+			t.Fatalf("replayed error = %v, want %v", err, sendErr)
+		}
+		if len(replayed) != 2 || !replayed[0].Replayed || replayed[0].MessageID != "m-1" {
+			t.Fatalf("replayed results = %+v", replayed)
+		}
+
+		if _, err := i.begin("k", "other request"); !errors.Is(err, ErrIdempotencyMismatch) {
+			t.Fatalf("error = %v, want %v", err, ErrIdempotencyMismatch)
+		}
+	})
+}
+
+func TestFileIdempotencyStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "idempotency.json")
+	store, err := NewFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &idempotency{store: store, window: time.Hour}
+	for _, key := range []string{"done", "released", "pending"} {
+		if _, err := i.begin(key, "fp"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	to := Recipient{ChannelJID: "sre@conference.xmpp.zoom.us"}
+	if err := i.finish("done", "fp", []SendResult{{Recipient: to, MessageID: "m-1"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.finish("released", "fp", []SendResult{{Recipient: to, Error: "failed"}}, errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process sees what the previous one recorded
+	reloaded, err := NewFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i = &idempotency{store: reloaded, window: time.Hour}
+
+	record, err := i.begin("done", "fp")
+	if err != nil || record == nil || record.Results[0].MessageID != "m-1" {
+		t.Fatalf("completed send after restart = %+v, %v", record, err)
+	}
+	if _, err := i.begin("pending", "fp"); !errors.Is(err, ErrIdempotencyPending) {
+		t.Fatalf("pending send after restart: error = %v, want %v", err, ErrIdempotencyPending)
+	}
+	if record, err := i.begin("released", "fp"); err != nil || record != nil {
+		t.Fatalf("released key after restart = %+v, %v, want a fresh claim", record, err)
+	}
+}
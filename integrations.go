@@ -0,0 +1,296 @@
+package zoomalert
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoDestination is returned when an integration has nowhere to deliver a notification
+var ErrNoDestination = errors.New("no destination configured")
+
+// resolvedColor is the header color of resolved/recovered notifications
+const resolvedColor = "#2DA44E"
+
+// maxIntegrationItems caps how many alerts of a grouped notification are rendered
+const maxIntegrationItems = 10
+
+// Destination is where an integration delivers its notifications
+type Destination struct {
+	Emails   []string `json:"emails,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+	// Bot optionally selects the sending chatbot by name
+	Bot string `json:"bot,omitempty"`
+	// Tenant optionally selects the Zoom account
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// recipients flattens the destination's emails and channels
+func (d Destination) recipients() []Recipient {
+	return RecipientFields{Emails: d.Emails, Channels: d.Channels}.recipients()
+}
+
+// validate checks the destination has valid recipients
+func (d Destination) validate() error {
+	tos := d.recipients()
+	if len(tos) == 0 {
+		return fmt.Errorf("destination needs at least one email or channel")
+	}
+	for _, to := range tos {
+		if err := to.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minHookTokenLength keeps incoming webhook URLs unguessable
+const minHookTokenLength = 16
+
+// HookTokens maps the secret tokens of incoming webhook URLs to destinations, for
+// compatibility endpoints whose URL is the only credential
+type HookTokens map[string]Destination
+
+// destination returns the destination bound to token
+func (t HookTokens) destination(token string) (Destination, bool) {
+	// Compare every token in constant time so the lookup leaks nothing about them
+	var found Destination
+	ok := false
+	for candidate, dest := range t {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			found, ok = dest, true
+		}
+	}
+	return found, ok
+}
+
+// validate checks token lengths and destinations
+func (t HookTokens) validate() error {
+	for token, dest := range t {
+		if len(token) < minHookTokenLength {
+			return fmt.Errorf("hook tokens must be at least %d characters", minHookTokenLength)
+		}
+		if err := dest.validate(); err != nil {
+			return fmt.Errorf("hook %s…: %w", token[:4], err)
+		}
+	}
+	return nil
+}
+
+// IntegrationsConfig configures the built-in webhook receivers. A receiver is
+// disabled while its section is nil.
+type IntegrationsConfig struct {
+	Alertmanager *AlertmanagerConfig `json:"alertmanager,omitempty"`
+	Grafana      *GrafanaConfig      `json:"grafana,omitempty"`
+	GitHub       *CICDConfig         `json:"github,omitempty"`
+	GitLab       *CICDConfig         `json:"gitlab,omitempty"`
+	Sentry       *SentryConfig       `json:"sentry,omitempty"`
+	CloudEvents  *CloudEventsConfig  `json:"cloudevents,omitempty"`
+	SNS          *SNSConfig          `json:"sns,omitempty"`
+	Slack        *SlackConfig        `json:"slack,omitempty"`
+	Teams        *TeamsConfig        `json:"teams,omitempty"`
+	// Webhooks maps names to generic JSON webhook mappings
+	Webhooks map[string]*WebhookConfig `json:"webhooks,omitempty"`
+	// Syslog configures a syslog listener rather than an HTTP receiver
+	Syslog *SyslogConfig `json:"syslog,omitempty"`
+}
+
+// LoadIntegrationsFile reads an IntegrationsConfig from a JSON file
+func LoadIntegrationsFile(filename string) (IntegrationsConfig, error) {
+	var integrations IntegrationsConfig
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return integrations, fmt.Errorf("failed to read integrations file: %w", err)
+	}
+	if err := json.Unmarshal(data, &integrations); err != nil {
+		return integrations, fmt.Errorf("failed to parse integrations file: %w", err)
+	}
+	return integrations, nil
+}
+
+// merge returns c with the sections configured in other replacing its own
+func (c IntegrationsConfig) merge(other IntegrationsConfig) IntegrationsConfig {
+	if other.Alertmanager != nil {
+		c.Alertmanager = other.Alertmanager
+	}
+	if other.Grafana != nil {
+		c.Grafana = other.Grafana
+	}
+	if other.GitHub != nil {
+		c.GitHub = other.GitHub
+	}
+	if other.GitLab != nil {
+		c.GitLab = other.GitLab
+	}
+	if other.Sentry != nil {
+		c.Sentry = other.Sentry
+	}
+	if other.CloudEvents != nil {
+		c.CloudEvents = other.CloudEvents
+	}
+	if other.SNS != nil {
+		c.SNS = other.SNS
+	}
+	if other.Slack != nil {
+		c.Slack = other.Slack
+	}
+	if other.Teams != nil {
+		c.Teams = other.Teams
+	}
+	if other.Webhooks != nil {
+		c.Webhooks = other.Webhooks
+	}
+	if other.Syslog != nil {
+		c.Syslog = other.Syslog
+	}
+	return c
+}
+
+// validateIntegrations checks that every configured destination can be delivered to
+func validateIntegrations(integrations IntegrationsConfig) error {
+	if integrations.Alertmanager != nil {
+		if err := integrations.Alertmanager.validate(); err != nil {
+			return fmt.Errorf("alertmanager: %w", err)
+		}
+	}
+	if integrations.Grafana != nil {
+		if err := integrations.Grafana.validate(); err != nil {
+			return fmt.Errorf("grafana: %w", err)
+		}
+	}
+	if integrations.GitHub != nil {
+		if err := integrations.GitHub.validate(); err != nil {
+			return fmt.Errorf("github: %w", err)
+		}
+	}
+	if integrations.GitLab != nil {
+		if err := integrations.GitLab.validate(); err != nil {
+			return fmt.Errorf("gitlab: %w", err)
+		}
+	}
+	if integrations.Sentry != nil {
+		if err := integrations.Sentry.validate(); err != nil {
+			return fmt.Errorf("sentry: %w", err)
+		}
+	}
+	if integrations.CloudEvents != nil {
+		if err := integrations.CloudEvents.validate(); err != nil {
+			return fmt.Errorf("cloudevents: %w", err)
+		}
+	}
+	if integrations.SNS != nil {
+		if err := integrations.SNS.validate(); err != nil {
+			return fmt.Errorf("sns: %w", err)
+		}
+	}
+	if integrations.Slack != nil {
+		if err := integrations.Slack.Hooks.validate(); err != nil {
+			return fmt.Errorf("slack: %w", err)
+		}
+	}
+	if integrations.Teams != nil {
+		if err := integrations.Teams.Hooks.validate(); err != nil {
+			return fmt.Errorf("teams: %w", err)
+		}
+	}
+	if _, err := newWebhookMappers(integrations.Webhooks); err != nil {
+		return fmt.Errorf("webhooks: %w", err)
+	}
+	if integrations.Syslog != nil {
+		if err := integrations.Syslog.validate(); err != nil {
+			return fmt.Errorf("syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// levelFromSeverity maps the severity names used by monitoring tools to alert levels
+func levelFromSeverity(severity string) AlertLevel {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "crit", "fatal", "emergency", "emerg", "alert", "page", "p1":
+		return AlertLevelCritical
+	case "error", "err", "high", "major", "p2":
+		return AlertLevelError
+	case "warning", "warn", "medium", "minor", "p3":
+		return AlertLevelWarning
+	}
+	return AlertLevelInfo
+}
+
+// maxLevel returns the more severe of two levels
+func maxLevel(a, b AlertLevel) AlertLevel {
+	if b.Rank() > a.Rank() {
+		return b
+	}
+	return a
+}
+
+// alertHeadline names an alert with its status and when it started or resolved
+func alertHeadline(name, status string, startsAt, endsAt time.Time) string {
+	switch {
+	case status == "resolved" && !endsAt.IsZero():
+		return fmt.Sprintf("**%s** (resolved at %s)", name, endsAt.UTC().Format(time.RFC3339))
+	case !startsAt.IsZero():
+		return fmt.Sprintf("**%s** (%s since %s)", name, status, startsAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("**%s** (%s)", name, status)
+}
+
+// imageBlock renders an image that links to link, or to the image itself
+func imageBlock(imageURL, link, title string) AttachmentBlock {
+	if link == "" {
+		link = imageURL
+	}
+	return AttachmentBlock{
+		Type:        "attachments",
+		ResourceURL: link,
+		ImageURL:    imageURL,
+		Information: AttachmentInformation{Title: ZoomSubhead{Text: title}},
+	}
+}
+
+// deliverIntegration sends an integration's message to its destination
+func (h *AlertHandler) deliverIntegration(w http.ResponseWriter, r *http.Request, dest Destination, msg message) {
+	h.dispatch(w, r, dest.recipients(), msg, integrationSendOptions(r, dest))
+}
+
+// integrationSendOptions returns the send options for a destination. The request's
+// tenant (path or header) takes precedence over the destination's.
+func integrationSendOptions(r *http.Request, dest Destination) sendOptions {
+	o := requestSendOptions(r, dest.Bot)
+	if o.tenant == "" {
+		o.tenant = dest.Tenant
+	}
+	return o
+}
+
+// readWebhookBody reads a webhook body for signature verification
+func readWebhookBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) > maxSignedBodyBytes {
+		return nil, fmt.Errorf("request body too large")
+	}
+	return body, nil
+}
+
+// integrationNotConfigured writes a 404 for a disabled integration
+func integrationNotConfigured(w http.ResponseWriter, name string) {
+	writeJSON(w, http.StatusNotFound, jsonObject{
+		"error": name + " integration is not configured",
+	})
+}
+
+// noDestination writes a 422 when a notification matches no configured destination
+func noDestination(w http.ResponseWriter, detail string) {
+	writeSendResults(w, nil, fmt.Errorf("%w for %s", ErrNoDestination, detail))
+}
@@ -0,0 +1,315 @@
+package zoomalert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Job statuses
+const (
+	JobQueued  = "queued"
+	JobSending = "sending"
+	JobSent    = "sent"
+	JobFailed  = "failed"
+)
+
+// Defaults for the asynchronous send queue
+const (
+	defaultAsyncWorkers   = 4
+	defaultAsyncQueueSize = 1000
+	defaultJobRetention   = time.Hour
+	jobCallbackTimeout    = 10 * time.Second
+)
+
+// Errors returned when submitting asynchronous sends
+var (
+	ErrQueueFull   = errors.New("send queue is full")
+	ErrQueueClosed = errors.New("send queue is shut down")
+)
+
+// Job is an asynchronous send and its outcome
+type Job struct {
+	ID        string       `json:"id"`
+	Status    string       `json:"status"`
+	MessageID string       `json:"message_id,omitempty"`
+	Results   []SendResult `json:"results,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	// owner is the submitting client's rate limit source; only it may read the job
+	owner       string
+	callbackURL string
+	delivery    *delivery
+}
+
+// jobQueue runs prepared deliveries on background workers. The workers start
+// with the first submitted job, so a module that never sends asynchronously
+// runs no goroutines for it.
+type jobQueue struct {
+	dispatcher  *dispatcher
+	queue       chan *Job
+	jobs        map[string]*Job
+	retention   time.Duration
+	workerCount int
+	started     bool
+	closed      bool
+	mutex       sync.Mutex
+	workers     sync.WaitGroup
+	client      *http.Client
+	logger      *slog.Logger
+}
+
+// newJobQueue creates a queue whose workers execute deliveries through d
+func newJobQueue(d *dispatcher, workers, size int, retention time.Duration, logger *slog.Logger) *jobQueue {
+	if workers <= 0 {
+		workers = defaultAsyncWorkers
+	}
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+	if retention <= 0 {
+		retention = defaultJobRetention
+	}
+
+	return &jobQueue{
+		dispatcher:  d,
+		queue:       make(chan *Job, size),
+		jobs:        make(map[string]*Job),
+		retention:   retention,
+		workerCount: workers,
+		client:      newCallbackClient(),
+		logger:      logger,
+	}
+}
+
+// startWorkers starts the workers on first use (must be called with mutex held)
+func (q *jobQueue) startWorkers() {
+	if q.started {
+		return
+	}
+	q.started = true
+	for i := 0; i < q.workerCount; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+}
+
+// submit queues a prepared delivery and returns its job
+func (q *jobQueue) submit(dl *delivery, callbackURL string) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	now := time.Now()
+	job := &Job{
+		ID:          id,
+		Status:      JobQueued,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		owner:       dl.o.source,
+		callbackURL: callbackURL,
+		delivery:    dl,
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return Job{}, ErrQueueClosed
+	}
+	q.cleanupExpired(now)
+	q.startWorkers()
+	select {
+	case q.queue <- job:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	q.jobs[id] = job
+	return *job, nil
+}
+
+// get returns a snapshot of a job if owner submitted it
+func (q *jobQueue) get(id, owner string) (Job, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.owner != owner {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// work executes queued jobs until the queue is closed and drained
+func (q *jobQueue) work() {
+	defer q.workers.Done()
+
+	for job := range q.queue {
+		q.update(job, func(j *Job) { j.Status = JobSending })
+
+		results, err := q.dispatcher.execute(job.delivery)
+
+		snapshot := q.update(job, func(j *Job) {
+			j.Results = results
+			j.delivery = nil
+			if len(results) == 1 {
+				j.MessageID = results[0].MessageID
+			}
+			if err != nil {
+				j.Status = JobFailed
+				j.Error = err.Error()
+			} else {
+				j.Status = JobSent
+			}
+		})
+
+		if job.callbackURL != "" {
+			q.notify(job.callbackURL, snapshot)
+		}
+	}
+}
+
+// update changes a job under the lock and returns a snapshot
+func (q *jobQueue) update(job *Job, change func(*Job)) Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	change(job)
+	job.UpdatedAt = time.Now()
+	return *job
+}
+
+// notify POSTs a finished job to the caller's callback URL
+func (q *jobQueue) notify(callbackURL string, job Job) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		q.logger.Error("Failed to marshal job callback", "job", job.ID, "error", err)
+		return
+	}
+
+	resp, err := q.client.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		q.logger.Warn("Job callback failed", "job", job.ID, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		q.logger.Warn("Job callback rejected", "job", job.ID, "status", resp.StatusCode)
+	}
+}
+
+// cleanupExpired forgets finished jobs past the retention period (must be called with mutex held)
+func (q *jobQueue) cleanupExpired(now time.Time) {
+	for id, job := range q.jobs {
+		finished := job.Status == JobSent || job.Status == JobFailed
+		if finished && now.Sub(job.UpdatedAt) > q.retention {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// stop rejects new jobs and waits for queued ones to finish or ctx to end
+func (q *jobQueue) stop(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for queued sends: %w", ctx.Err())
+	}
+}
+
+// newJobID returns a random job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validateCallbackURL checks a job callback is an absolute http(s) URL. Hosts
+// given as an internal IP address are rejected here; names are checked again
+// when the callback is dialed.
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: callback_url must be an absolute http(s) URL", ErrInvalidMessage)
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !publicAddress(ip) {
+		return fmt.Errorf("%w: callback_url must not point to an internal address", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which some
+// clouds use for metadata services
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether ip is a globally routable unicast address, so a
+// caller-supplied callback cannot reach loopback, private or link-local services
+// such as cloud metadata endpoints
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// newCallbackClient returns the HTTP client for job callbacks. Its dialer
+// checks every resolved address, so DNS names that resolve to internal
+// addresses are refused too. Proxies are not used, since the check would then
+// apply to the proxy instead of the callback host.
+func newCallbackClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: jobCallbackTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("callback address %s: %w", address, err)
+			}
+			if !publicAddress(addrPort.Addr()) {
+				return fmt.Errorf("callback address %s is not a public address", address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: jobCallbackTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: jobCallbackTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.10.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		// IPv4-mapped IPv6 addresses are checked as the IPv4 address they carry
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+	}
+	for _, tt := range tests {
+		if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCallbackURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://hooks.example.com/jobs", false},
+		{"http://93.184.216.34:8080/done", false},
+		// Names are checked when dialed, since they may resolve differently later
+		{"https://localhost/jobs", false},
+		{"ftp://hooks.example.com/jobs", true},
+		{"/relative/path", true},
+		{"https://", true},
+		{"http://127.0.0.1/jobs", true},
+		{"http://10.0.0.5/jobs", true},
+		{"http://192.168.0.10:8080/jobs", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]/jobs", true},
+		{"http://[fe80::1%25eth0]/jobs", true},
+		{"http://[::ffff:127.0.0.1]/jobs", true},
+		{"http://[::ffff:a9fe:a9fe]/jobs", true},
+	}
+	for _, tt := range tests {
+		if err := validateCallbackURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("validateCallbackURL(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+// A name that passed validation but resolves to an internal address, as after DNS
+// rebinding, is refused by the dialer
+func TestCallbackClientRefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client := newCallbackClient()
+	// The IP form is rejected up front as well, but the dialer mustn't rely on that
+	for _, target := range []string{
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+		server.URL,
+	} {
+		resp, err := client.Post(target, "application/json", strings.NewReader(`{}`))
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("callback to %s was delivered", target)
+		}
+		if !strings.Contains(err.Error(), "not a public address") {
+			t.Fatalf("callback to %s failed with %v, want the dialer to refuse it", target, err)
+		}
+	}
+	if called {
+		t.Fatal("internal server received a callback")
+	}
+}
+
+func TestGetJobOwner(t *testing.T) {
+	m := newTestModule(t)
+	handler := m.Handler()
+
+	get := func(id, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without credentials configured, the owner is the submitter's address
+	now := time.Now()
+	q := m.dispatcher.jobs
+	q.mutex.Lock()
+	q.jobs["job-1"] = &Job{ID: "job-1", Status: JobQueued, CreatedAt: now, UpdatedAt: now, owner: "addr:192.0.2.10"}
+	q.mutex.Unlock()
+
+	rec := get("job-1", "192.0.2.10:5000")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("owner status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var job Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil || job.ID != "job-1" || job.Status != JobQueued {
+		t.Fatalf("owner got %s (%v)", rec.Body, err)
+	}
+
+	// Other callers can't tell the job from a missing one
+	for _, remoteAddr := range []string{"198.51.100.7:5000", "192.0.2.11:5000"} {
+		if rec := get("job-1", remoteAddr); rec.Code != http.StatusNotFound {
+			t.Fatalf("status for %s = %d, want %d: %s", remoteAddr, rec.Code, http.StatusNotFound, rec.Body)
+		}
+	}
+	if rec := get("job-2", "192.0.2.10:5000"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown job status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,171 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathStepKind is the kind of one step of a JSON path
+type pathStepKind int
+
+const (
+	// stepKey selects an object member, or an array element when the key is a number
+	stepKey pathStepKind = iota
+	// stepIndex selects an array element; negative indexes count from the end
+	stepIndex
+	// stepWildcard selects every element of an array or value of an object
+	stepWildcard
+)
+
+// pathStep is one step of a JSON path
+type pathStep struct {
+	kind  pathStepKind
+	key   string
+	index int
+}
+
+// parseJSONPath parses the JSONPath subset and gjson-style paths used by webhook
+// mappings: $.a.b[0], a.b.0, a['b.c'], a[*].b, a.#.b, a[-1]. "$" and "" select
+// the whole document.
+func parseJSONPath(expr string) ([]pathStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	var steps []pathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, fmt.Errorf("invalid path %q: empty key", expr)
+			}
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed [", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{kind: stepWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{kind: stepKey, key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad index [%s]", expr, inner)
+				}
+				steps = append(steps, pathStep{kind: stepIndex, index: index})
+			}
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "#" || key == "*" {
+				steps = append(steps, pathStep{kind: stepWildcard})
+			} else {
+				steps = append(steps, pathStep{kind: stepKey, key: key})
+			}
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath applies a parsed path to a decoded JSON document. Missing members
+// yield nil; wildcards yield a list of the non-nil results.
+func evalJSONPath(v any, steps []pathStep) any {
+	for i, step := range steps {
+		switch step.kind {
+		case stepKey:
+			switch node := v.(type) {
+			case map[string]any:
+				v = node[step.key]
+			case []any:
+				index, err := strconv.Atoi(step.key)
+				if err != nil {
+					return nil
+				}
+				v = arrayElement(node, index)
+			default:
+				return nil
+			}
+
+		case stepIndex:
+			node, ok := v.([]any)
+			if !ok {
+				return nil
+			}
+			v = arrayElement(node, step.index)
+
+		case stepWildcard:
+			var elements []any
+			switch node := v.(type) {
+			case []any:
+				elements = node
+			case map[string]any:
+				keys := make([]string, 0, len(node))
+				for k := range node {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					elements = append(elements, node[k])
+				}
+			default:
+				return nil
+			}
+			results := []any{}
+			for _, el := range elements {
+				if result := evalJSONPath(el, steps[i+1:]); result != nil {
+					results = append(results, result)
+				}
+			}
+			return results
+		}
+		if v == nil {
+			return nil
+		}
+	}
+	return v
+}
+
+// arrayElement returns element index of an array, counting from the end when negative
+func arrayElement(array []any, index int) any {
+	if index < 0 {
+		index += len(array)
+	}
+	if index < 0 || index >= len(array) {
+		return nil
+	}
+	return array[index]
+}
+
+// jsonText renders an extracted value as text: strings as they are, lists joined
+// with commas and objects as JSON
+func jsonText(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, el := range value {
+			if text := jsonText(el); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,127 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJSONPathDocument = `{
+	"alert": {"name": "High CPU", "tags": ["prod", "db"], "dotted.key": "yes"},
+	"items": [
+		{"id": 1, "links": [{"url": "https://a.example.com"}, {"url": "https://b.example.com"}]},
+		{"id": 2, "links": []},
+		{"id": 3, "links": [{"url": "https://c.example.com"}, {"name": "no url"}]}
+	],
+	"labels": {"team": "sre", "env": "prod"},
+	"count": 12345678901234567890
+}`
+
+func TestEvalJSONPath(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(testJSONPathDocument))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.alert.name", "High CPU"},
+		{"alert.name", "High CPU"},
+		{"$['alert']['name']", "High CPU"},
+		{`alert["dotted.key"]`, "yes"},
+		{"alert.tags[0]", "prod"},
+		{"alert.tags.1", "db"},
+		{"alert.tags[-1]", "db"},
+		{"count", json.Number("12345678901234567890")},
+
+		// Missing keys and out-of-range indexes yield nothing
+		{"alert.missing", nil},
+		{"alert.missing.deeper", nil},
+		{"alert.tags[2]", nil},
+		{"alert.tags[-3]", nil},
+		{"alert.name.first", nil},
+		{"alert.tags.first", nil},
+		{"items[0][0]", nil},
+
+		// Wildcards collect the non-missing results
+		{"alert.tags[*]", []any{"prod", "db"}},
+		{"items.#.id", []any{json.Number("1"), json.Number("2"), json.Number("3")}},
+		{"items[*].missing", []any{}},
+		{"labels.*", []any{"prod", "sre"}},
+		{"alert.name[*]", nil},
+
+		// Nested wildcards give a list per outer element
+		{"items[*].links[*].url", []any{
+			[]any{"https://a.example.com", "https://b.example.com"},
+			[]any{},
+			[]any{"https://c.example.com"},
+		}},
+		{"items.#.links.#.url", []any{
+			[]any{"https://a.example.com", "https://b.example.com"},
+			[]any{},
+			[]any{"https://c.example.com"},
+		}},
+		{"items[-1].links[*].name", []any{"no url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			steps, err := parseJSONPath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := evalJSONPath(doc, steps); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("evalJSONPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	for _, path := range []string{"", "$"} {
+		steps, err := parseJSONPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := evalJSONPath(doc, steps); !reflect.DeepEqual(got, doc) {
+			t.Fatalf("%q selected %#v, want the whole document", path, got)
+		}
+	}
+}
+
+func TestParseJSONPathErrors(t *testing.T) {
+	for _, path := range []string{
+		"alert.",
+		"alert..name",
+		"alert.[0]",
+		"alert.tags[0",
+		"alert.tags[x]",
+		"alert.tags['unterminated]",
+	} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("parseJSONPath(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestJSONText(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{json.Number("42"), "42"},
+		{true, "true"},
+		{[]any{"a", nil, "", []any{"b", "c"}}, "a, b, c"},
+		{map[string]any{"k": "v"}, `{"k":"v"}`},
+	}
+	for _, tt := range tests {
+		if got := jsonText(tt.value); got != tt.want {
+			t.Errorf("jsonText(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
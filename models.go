@@ -52,3 +52,13 @@ type ActionsBlock struct {
 	Type  string   `json:"type"`
 	Items []Action `json:"items"`
 }
+type AttachmentBlock struct {
+	Type        string                `json:"type"`
+	ResourceURL string                `json:"resource_url"`
+	ImageURL    string                `json:"img_url"`
+	Information AttachmentInformation `json:"information"`
+}
+type AttachmentInformation struct {
+	Title       ZoomSubhead `json:"title"`
+	Description ZoomSubhead `json:"description"`
+}
@@ -3,13 +3,17 @@ package zoomalert
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
@@ -22,13 +26,86 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithStateStore sets a shared StateStore for OAuth state parameters, e.g. one
+// backed by a database so callbacks can be handled by any replica. In signed state
+// mode the store records used nonces, making states single-use across replicas.
+func WithStateStore(store StateStore) Option {
+	return func(m *ZoomAlertModule) {
+		m.stateStore = store
+	}
+}
+
+// WithIdempotencyStore replaces the idempotency store selected by Config.IdempotencyStore,
+// e.g. with one backed by shared storage
+func WithIdempotencyStore(store IdempotencyStore) Option {
+	return func(m *ZoomAlertModule) {
+		m.idempotencyStore = store
+	}
+}
+
+// WithSNSCertificateFetcher replaces how SNS signing certificates are downloaded,
+// e.g. with a local stand-in in tests
+func WithSNSCertificateFetcher(fetcher SNSCertificateFetcher) Option {
+	return func(m *ZoomAlertModule) {
+		m.snsCertificateFetcher = fetcher
+	}
+}
+
+// WithTenant registers an additional Zoom account with its own credentials,
+// robot JID and token store
+func WithTenant(id string, config *Config) Option {
+	return func(m *ZoomAlertModule) {
+		m.extraTenants = append(m.extraTenants, tenantConfig{id: id, config: config})
+	}
+}
+
+// WithTemplate registers a named message template
+func WithTemplate(name string, template TemplateConfig) Option {
+	return func(m *ZoomAlertModule) {
+		m.extraTemplates = append(m.extraTemplates, namedTemplate{name: name, config: template})
+	}
+}
+
+// namedTemplate is a template registered through WithTemplate
+type namedTemplate struct {
+	name   string
+	config TemplateConfig
+}
+
+// tenantConfig is a tenant registered through WithTenant
+type tenantConfig struct {
+	id     string
+	config *Config
+}
+
 // ZoomAlertModule represents the main module that can be integrated into other projects
 type ZoomAlertModule struct {
 	config       *Config
 	oauthService *OAuthService
 	zoomService  *ZoomService
-	server       *http.Server
-	logger       *slog.Logger
+	tenants      *tenantRegistry
+	extraTenants []tenantConfig
+	// Send pipeline and message templates
+	dispatcher     *dispatcher
+	templates      *TemplateRegistry
+	extraTemplates []namedTemplate
+	auth           *authenticator
+	server         *http.Server
+	serverMutex    sync.Mutex
+	inflight       sync.WaitGroup
+	logger         *slog.Logger
+	stateStore     StateStore
+	// idempotencyStore is set by WithIdempotencyStore
+	idempotencyStore IdempotencyStore
+	// integrations configures the built-in webhook receivers
+	integrations IntegrationsConfig
+	// snsCertificateFetcher is set by WithSNSCertificateFetcher
+	snsCertificateFetcher SNSCertificateFetcher
+	sns                   *snsVerifier
+	// webhooks are the compiled generic webhook mappings
+	webhooks map[string]*webhookMapper
+	// syslog is the syslog listener, nil unless configured
+	syslog *syslogReceiver
 }
 
 // Config holds the configuration for the Zoom Alert Service
@@ -40,13 +117,96 @@ type Config struct {
 	ZoomRobotJID     string
 	Port             string
 	TokenFilePath    string
+	// UnixSocket makes the HTTP server listen on a Unix socket instead of Port
+	UnixSocket string
+	// TLSCertFile and TLSKeyFile enable HTTPS
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile enables mutual TLS, requiring client certificates signed by this CA
+	TLSClientCAFile string
+	// ShutdownTimeout bounds how long shutdown drains in-flight requests and sends
+	ShutdownTimeout time.Duration
+	// DisablePKCE turns off PKCE for Zoom apps that do not support it
+	DisablePKCE bool
+	// OAuthStateMode selects how OAuth state parameters are tracked ("memory" or "signed")
+	OAuthStateMode string
+	// OAuthStateSecret is the HMAC key for signed state parameters, shared by all replicas
+	OAuthStateSecret string
+	// ZoomWebhookSecretToken verifies Zoom event notifications (e.g. app deauthorization)
+	ZoomWebhookSecretToken string
+	// RequiredScopes are verified against the scopes granted at authorization
+	RequiredScopes []string
+	// OAuthReturnTo is the default URL the browser is redirected to after authorization
+	OAuthReturnTo string
+	// OAuthReturnToAllowlist lists the URL prefixes accepted as return_to targets
+	OAuthReturnToAllowlist []string
+	// Bots holds additional chatbots by name; "default" is ZoomRobotJID unless overridden
+	Bots map[string]BotConfig
+	// Tenants holds additional Zoom accounts keyed by tenant id
+	Tenants map[string]*Config
+	// TemplateDir is a directory of *.tmpl message templates
+	TemplateDir string
+	// Templates holds additional message templates by name
+	Templates map[string]TemplateConfig
+	// APIKeys are the accepted alert API keys, stored as SHA-256 hashes
+	APIKeys []APIKey
+	// HMACClients may authenticate alert requests with HMAC signatures
+	HMACClients []HMACClient
+	// HMACReplayWindow is how far a signed request's timestamp may drift from now
+	HMACReplayWindow time.Duration
+	// AuthProtectOAuth also requires credentials on the OAuth admin routes
+	// (status, authorize, start, revoke); the callback and Zoom events stay open
+	AuthProtectOAuth bool
+	// AuthProtectHealth also requires credentials on the health check
+	AuthProtectHealth bool
+	// Policies restrict what each API client may send, keyed by client ID
+	// (API key label or HMAC client ID); "*" applies to clients without their own
+	Policies map[string]Policy
+	// PoliciesFile is a JSON file of client policies, merged over Policies
+	PoliciesFile string
+	// ClientRateLimit limits sends per API client (or remote address without auth)
+	ClientRateLimit RateLimit
+	// RecipientRateLimit limits sends per recipient
+	RecipientRateLimit RateLimit
+	// RateLimitBypassLevel lets alerts at or above this level skip the client and
+	// recipient limits; empty disables the bypass
+	RateLimitBypassLevel AlertLevel
+	// OutboundRateLimit paces chat messages sent to Zoom per tenant
+	OutboundRateLimit RateLimit
+	// IdempotencyWindow is how long a send is remembered by its idempotency key
+	IdempotencyWindow time.Duration
+	// IdempotencyStore selects where idempotency keys are kept ("memory" or "file")
+	IdempotencyStore string
+	// IdempotencyFilePath is the file used by the "file" idempotency store
+	// (defaults to idempotency.json next to the token file)
+	IdempotencyFilePath string
+	// AsyncWorkers is the number of background workers for ?async=true sends
+	AsyncWorkers int
+	// AsyncQueueSize bounds the sends waiting for a worker
+	AsyncQueueSize int
+	// JobRetention is how long finished jobs can be polled
+	JobRetention time.Duration
+	// Integrations configures the built-in webhook receivers (Alertmanager, ...)
+	Integrations IntegrationsConfig
+	// IntegrationsFile is a JSON file of integrations; its sections replace those
+	// in Integrations
+	IntegrationsFile string
 }
 
 // DefaultConfig returns a configuration with default values
 func DefaultConfig() *Config {
 	return &Config{
-		Port:          "8080",
-		TokenFilePath: "./tokens.json",
+		Port:              "8080",
+		TokenFilePath:     "./tokens.json",
+		ShutdownTimeout:   defaultShutdownTimeout,
+		OAuthStateMode:    StateModeMemory,
+		RequiredScopes:    append([]string{}, DefaultRequiredScopes...),
+		OutboundRateLimit: defaultOutboundRateLimit,
+		IdempotencyWindow: defaultIdempotencyWindow,
+		IdempotencyStore:  IdempotencyStoreMemory,
+		AsyncWorkers:      defaultAsyncWorkers,
+		AsyncQueueSize:    defaultAsyncQueueSize,
+		JobRetention:      defaultJobRetention,
 	}
 }
 
@@ -78,10 +238,120 @@ func LoadConfigFromEnv() *Config {
 	if val := os.Getenv("TOKEN_FILE_PATH"); val != "" {
 		config.TokenFilePath = val
 	}
+	if val := os.Getenv("UNIX_SOCKET"); val != "" {
+		config.UnixSocket = val
+	}
+	if val := os.Getenv("TLS_CERT_FILE"); val != "" {
+		config.TLSCertFile = val
+	}
+	if val := os.Getenv("TLS_KEY_FILE"); val != "" {
+		config.TLSKeyFile = val
+	}
+	if val := os.Getenv("TLS_CLIENT_CA_FILE"); val != "" {
+		config.TLSClientCAFile = val
+	}
+	if val := os.Getenv("SHUTDOWN_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.ShutdownTimeout = d
+		} else {
+			slog.Warn("Ignoring invalid SHUTDOWN_TIMEOUT", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("ZOOM_DISABLE_PKCE"); val != "" {
+		config.DisablePKCE = parseBool(val)
+	}
+	if val := os.Getenv("ZOOM_OAUTH_STATE_MODE"); val != "" {
+		config.OAuthStateMode = val
+	}
+	if val := os.Getenv("ZOOM_OAUTH_STATE_SECRET"); val != "" {
+		config.OAuthStateSecret = val
+	}
+	if val := os.Getenv("ZOOM_WEBHOOK_SECRET_TOKEN"); val != "" {
+		config.ZoomWebhookSecretToken = val
+	}
+	if val := os.Getenv("ZOOM_REQUIRED_SCOPES"); val != "" {
+		config.RequiredScopes = splitList(val)
+	}
+	if val := os.Getenv("ZOOM_OAUTH_RETURN_TO"); val != "" {
+		config.OAuthReturnTo = val
+	}
+	if val := os.Getenv("ZOOM_OAUTH_RETURN_TO_ALLOWLIST"); val != "" {
+		config.OAuthReturnToAllowlist = splitList(val)
+	}
+	config.Bots = loadBotConfigsFromEnv("ZOOM_")
+	if val := os.Getenv("ALERT_TEMPLATE_DIR"); val != "" {
+		config.TemplateDir = val
+	}
+
+	loadAuthFromEnv(config)
+	if val := os.Getenv("ALERT_POLICIES_FILE"); val != "" {
+		config.PoliciesFile = val
+	}
+	loadRateLimitsFromEnv(config)
+	if val := os.Getenv("ALERT_IDEMPOTENCY_WINDOW"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.IdempotencyWindow = d
+		} else {
+			slog.Warn("Ignoring invalid ALERT_IDEMPOTENCY_WINDOW", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("ALERT_IDEMPOTENCY_STORE"); val != "" {
+		config.IdempotencyStore = val
+	}
+	if val := os.Getenv("ALERT_IDEMPOTENCY_FILE"); val != "" {
+		config.IdempotencyFilePath = val
+	}
+	if val := os.Getenv("ALERT_ASYNC_WORKERS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			config.AsyncWorkers = n
+		} else {
+			slog.Warn("Ignoring invalid ALERT_ASYNC_WORKERS", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("ALERT_ASYNC_QUEUE_SIZE"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			config.AsyncQueueSize = n
+		} else {
+			slog.Warn("Ignoring invalid ALERT_ASYNC_QUEUE_SIZE", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("ALERT_JOB_RETENTION"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.JobRetention = d
+		} else {
+			slog.Warn("Ignoring invalid ALERT_JOB_RETENTION", "value", val, "error", err)
+		}
+	}
+	if val := os.Getenv("INTEGRATIONS_CONFIG_FILE"); val != "" {
+		config.IntegrationsFile = val
+	}
+
+	// Tenants inherit the settings above, so they are loaded last
+	config.Tenants = loadTenantConfigsFromEnv(config)
 
 	return config
 }
 
+// splitList splits a comma-separated environment value, dropping empty entries
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseBool interprets common truthy environment values
+func parseBool(val string) bool {
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return val == "yes" || val == "on"
+	}
+	return b
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	if c.ZoomAccountID == "" {
@@ -93,6 +363,35 @@ func (c *Config) Validate() error {
 	if c.ZoomClientSecret == "" {
 		return fmt.Errorf("ZOOM_CLIENT_SECRET is required")
 	}
+	switch c.OAuthStateMode {
+	case "", StateModeMemory:
+	case StateModeSigned:
+		if len(c.OAuthStateSecret) < 32 {
+			return fmt.Errorf("ZOOM_OAUTH_STATE_SECRET must be at least 32 characters for signed state mode")
+		}
+	default:
+		return fmt.Errorf("unknown ZOOM_OAUTH_STATE_MODE %q", c.OAuthStateMode)
+	}
+	if err := validateReturnTo(c.OAuthReturnTo, c.OAuthReturnToAllowlist); err != nil {
+		return fmt.Errorf("invalid ZOOM_OAUTH_RETURN_TO: %w", err)
+	}
+	if err := validateBots(c.Bots); err != nil {
+		return err
+	}
+	if err := validateAuth(c); err != nil {
+		return err
+	}
+	if err := validateIntegrations(c.Integrations); err != nil {
+		return err
+	}
+	if err := validatePolicies(c.Policies); err != nil {
+		return err
+	}
+	switch c.IdempotencyStore {
+	case "", IdempotencyStoreMemory, IdempotencyStoreFile:
+	default:
+		return fmt.Errorf("unknown ALERT_IDEMPOTENCY_STORE %q", c.IdempotencyStore)
+	}
 	return nil
 }
 
@@ -111,29 +410,207 @@ func NewZoomAlertModule(config *Config, options ...Option) (*ZoomAlertModule, er
 		opt(ms)
 	}
 
-	// Initialize services
-	ms.oauthService = NewOAuthService(config, ms.logger, config.TokenFilePath)
-	ms.zoomService = NewZoomService(ms.oauthService, config.ZoomRobotJID, config.ZoomAccountID, ms.logger)
+	// Initialize services for the default tenant
+	def := newTenant(DefaultTenantID, config, ms.logger, ms.stateStore)
+	ms.tenants = newTenantRegistry(def)
+	ms.oauthService = def.oauthService
+	ms.zoomService = def.zoomService
+
+	// Initialize additional tenants from config and options
+	extra := make([]tenantConfig, 0, len(config.Tenants)+len(ms.extraTenants))
+	for id, cfg := range config.Tenants {
+		extra = append(extra, tenantConfig{id: id, config: cfg})
+	}
+	extra = append(extra, ms.extraTenants...)
+
+	tokenFiles := map[string]string{config.TokenFilePath: DefaultTenantID}
+	for _, tc := range extra {
+		if !tenantIDPattern.MatchString(tc.id) {
+			return nil, fmt.Errorf("invalid tenant id %q", tc.id)
+		}
+		if err := tc.config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid configuration for tenant %s: %w", tc.id, err)
+		}
+		if owner, taken := tokenFiles[tc.config.TokenFilePath]; taken {
+			return nil, fmt.Errorf("tenant %s shares token file %s with tenant %s", tc.id, tc.config.TokenFilePath, owner)
+		}
+		tokenFiles[tc.config.TokenFilePath] = tc.id
+
+		if err := ms.tenants.add(newTenant(tc.id, tc.config, ms.logger, ms.stateStore)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load message templates: built-ins, then the template directory, then explicit ones
+	ms.templates = NewTemplateRegistry()
+	if config.TemplateDir != "" {
+		dirTemplates, err := LoadTemplateDir(config.TemplateDir)
+		if err != nil {
+			return nil, err
+		}
+		for name, tc := range dirTemplates {
+			if err := ms.templates.Register(name, tc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, tc := range config.Templates {
+		if err := ms.templates.Register(name, tc); err != nil {
+			return nil, err
+		}
+	}
+	for _, nt := range ms.extraTemplates {
+		if err := ms.templates.Register(nt.name, nt.config); err != nil {
+			return nil, err
+		}
+	}
+
+	ms.auth = newAuthenticator(config, ms.logger)
+	if ms.auth == nil {
+		ms.logger.Warn("No API keys or HMAC clients configured; alert endpoints are unauthenticated")
+	}
+
+	policies := make(map[string]Policy, len(config.Policies))
+	for client, policy := range config.Policies {
+		policies[client] = policy
+	}
+	if config.PoliciesFile != "" {
+		filePolicies, err := LoadPolicyFile(config.PoliciesFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := validatePolicies(filePolicies); err != nil {
+			return nil, err
+		}
+		for client, policy := range filePolicies {
+			policies[client] = policy
+		}
+	}
+
+	idempotencyStore := ms.idempotencyStore
+	if idempotencyStore == nil {
+		if config.IdempotencyStore == IdempotencyStoreFile {
+			path := config.IdempotencyFilePath
+			if path == "" {
+				path = filepath.Join(filepath.Dir(config.TokenFilePath), "idempotency.json")
+			}
+			fileStore, err := NewFileIdempotencyStore(path)
+			if err != nil {
+				return nil, err
+			}
+			idempotencyStore = fileStore
+		} else {
+			idempotencyStore = NewMemoryIdempotencyStore()
+		}
+	}
+	idempotencyWindow := config.IdempotencyWindow
+	if idempotencyWindow <= 0 {
+		idempotencyWindow = defaultIdempotencyWindow
+	}
+
+	ms.dispatcher = &dispatcher{
+		tenants:   ms.tenants,
+		templates: ms.templates,
+		policies:  newPolicySet(policies),
+		limits: sendLimits{
+			client:      newRateLimiter(config.ClientRateLimit),
+			recipient:   newRateLimiter(config.RecipientRateLimit),
+			outbound:    newRateLimiter(config.OutboundRateLimit),
+			bypassLevel: config.RateLimitBypassLevel,
+		},
+		idempotency: &idempotency{
+			store:  idempotencyStore,
+			window: idempotencyWindow,
+		},
+		logger:   ms.logger,
+		inflight: &ms.inflight,
+	}
+
+	ms.integrations = config.Integrations
+	if config.IntegrationsFile != "" {
+		fileIntegrations, err := LoadIntegrationsFile(config.IntegrationsFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateIntegrations(fileIntegrations); err != nil {
+			return nil, err
+		}
+		ms.integrations = ms.integrations.merge(fileIntegrations)
+	}
+	if ms.integrations.SNS != nil {
+		ms.sns = newSNSVerifier(ms.snsCertificateFetcher)
+	}
+	webhooks, err := newWebhookMappers(ms.integrations.Webhooks)
+	if err != nil {
+		return nil, err
+	}
+	ms.webhooks = webhooks
+	if ms.integrations.Syslog != nil {
+		if ms.syslog, err = newSyslogReceiver(ms.integrations.Syslog, ms.dispatcher, ms.logger); err != nil {
+			return nil, err
+		}
+	}
+	if ce := ms.integrations.CloudEvents; ce != nil {
+		for i, rule := range ce.Rules {
+			if rule.Template != "" && !ms.templates.Has(rule.Template) {
+				return nil, fmt.Errorf("cloudevents rule %d: %w %q", i, ErrUnknownTemplate, rule.Template)
+			}
+		}
+	}
+
+	ms.dispatcher.jobs = newJobQueue(ms.dispatcher, config.AsyncWorkers, config.AsyncQueueSize, config.JobRetention, ms.logger)
 
 	return ms, nil
 }
 
 // SendMessage sends a message to a Zoom user by email
-func (m *ZoomAlertModule) SendMessage(email string, message ZoomContent) error {
-	if !m.zoomService.IsUserAuthorized() {
-		return fmt.Errorf("user is not authorized")
+func (m *ZoomAlertModule) SendMessage(email string, message ZoomContent, opts ...SendOption) error {
+	if email == "" {
+		return fmt.Errorf("email is required")
 	}
 
+	_, err := m.Send(Recipient{Email: email}, message, opts...)
+	return err
+}
+
+// Send delivers rich content to a user or channel and returns Zoom's message ID
+func (m *ZoomAlertModule) Send(to Recipient, message ZoomContent, opts ...SendOption) (*SendResult, error) {
+	return m.dispatcher.send(to, message, collectSendOptions(opts))
+}
+
+// SendAlert sends a plain text alert to a Zoom user by email
+func (m *ZoomAlertModule) SendAlert(email, message string, opts ...SendOption) error {
 	if email == "" {
 		return fmt.Errorf("email is required")
 	}
 
-	if err := m.zoomService.SendMessageByEmail(email, message); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
+	_, err := m.Send(Recipient{Email: email}, ZoomContent{Head: ZoomHead{Text: message}}, opts...)
+	return err
+}
 
-	m.logger.Info("Message sent successfully", "email", email)
-	return nil
+// SendSeverityAlert renders a severity-colored alert and sends it to a user or channel
+func (m *ZoomAlertModule) SendSeverityAlert(to Recipient, alert Alert, opts ...SendOption) (*SendResult, error) {
+	return m.dispatcher.sendAlert(to, alert, collectSendOptions(opts))
+}
+
+// SendTemplatedAlert renders the named template with data and sends it to a user or channel
+func (m *ZoomAlertModule) SendTemplatedAlert(to Recipient, template string, data any, opts ...SendOption) (*SendResult, error) {
+	return m.dispatcher.sendTemplate(to, template, data, collectSendOptions(opts))
+}
+
+// Templates returns the module's message template registry
+func (m *ZoomAlertModule) Templates() *TemplateRegistry {
+	return m.templates
+}
+
+// Tenant returns the tenant with the given id; an empty id returns the default tenant
+func (m *ZoomAlertModule) Tenant(id string) (*Tenant, error) {
+	return m.tenants.get(id)
+}
+
+// Tenants returns the identifiers of all configured tenants
+func (m *ZoomAlertModule) Tenants() []string {
+	return m.tenants.ids()
 }
 
 // IsUserAuthorized checks if the module has user authorization
@@ -141,6 +618,11 @@ func (m *ZoomAlertModule) IsUserAuthorized() bool {
 	return m.zoomService.IsUserAuthorized()
 }
 
+// AuthStatus returns who authorized the module, granted scopes and token expiry times
+func (m *ZoomAlertModule) AuthStatus() AuthStatus {
+	return m.zoomService.AuthStatus()
+}
+
 // GetAuthorizationURL returns the OAuth authorization URL
 func (m *ZoomAlertModule) GetAuthorizationURL() (string, error) {
 	state, err := m.oauthService.GenerateState()
@@ -152,40 +634,49 @@ func (m *ZoomAlertModule) GetAuthorizationURL() (string, error) {
 	return url, nil
 }
 
-// HandleOAuthCallback processes the OAuth callback
+// HandleOAuthCallback processes the OAuth callback, storing the tokens with the
+// tenant that issued the state
 func (m *ZoomAlertModule) HandleOAuthCallback(code, state string) error {
-	if err := m.oauthService.ValidateState(state); err != nil {
-		return fmt.Errorf("invalid state: %w", err)
-	}
+	return m.tenants.forState(state).HandleOAuthCallback(code, state)
+}
 
-	return m.oauthService.ExchangeCodeForToken(code)
+// RevokeAuthorization revokes the user authorization at Zoom and clears stored tokens
+func (m *ZoomAlertModule) RevokeAuthorization() error {
+	return m.oauthService.Revoke()
 }
 
-// Shutdown gracefully shuts down the HTTP server
+// Shutdown gracefully shuts down the HTTP server and syslog listener and waits for
+// in-flight sends
 func (m *ZoomAlertModule) Shutdown() error {
-	if m.server == nil {
-		return nil
+	timeout := m.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
 	}
-
-	m.logger.Info("Shutting down HTTP server")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	return m.server.Shutdown(ctx)
-}
+	m.serverMutex.Lock()
+	server := m.server
+	m.server = nil
+	m.serverMutex.Unlock()
+
+	var serverErr error
+	if server != nil {
+		m.logger.Info("Shutting down HTTP server")
+		serverErr = server.Shutdown(ctx)
+	}
+	// Syslog readers stop before the job queue so no alert is queued after it closes
+	if m.syslog != nil {
+		m.syslog.stop()
+	}
 
-// RegisterOAuthRoutes sets up the OAuth routes on an existing Gin router
-func (m *ZoomAlertModule) RegisterOAuthRoutes(router *gin.Engine) {
-	alertHandler := NewAlertHandler(m.zoomService)
+	// Queued asynchronous sends are still delivered before shutdown completes
+	jobsErr := m.dispatcher.jobs.stop(ctx)
 
-	v1 := router.Group("/api/v1")
-	{
-		v1.GET("/health", alertHandler.HealthCheck)
-		v1.GET("/auth/status", alertHandler.GetAuthStatus)
-		v1.GET("/oauth/callback", alertHandler.OAuthCallback)
-		v1.GET("/oauth/authorize", alertHandler.OAuthAuthorize)
+	if err := m.waitForSends(ctx); err != nil {
+		return errors.Join(serverErr, jobsErr, err)
 	}
+	return errors.Join(serverErr, jobsErr)
 }
 
 // GetZoomService returns the underlying ZoomService for advanced usage
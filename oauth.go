@@ -3,6 +3,7 @@ package zoomalert
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -21,11 +22,19 @@ type OAuthService struct {
 	userAccessToken  string
 	userRefreshToken string
 	userExpiresAt    time.Time
+	// Authorization details recorded at code exchange
+	refreshExpiresAt time.Time
+	grantedScopes    []string
+	authorizedBy     *User
+	authorizedAt     time.Time
+	tokenMutex       sync.Mutex
 	// State management for OAuth flow
-	stateStore map[string]StateInfo
-	stateMutex sync.RWMutex
+	stateStore  StateStore
+	stateSigner *stateSigner
 	// Token persistence
 	tokenFilePath string
+	// tenantID binds issued states to the owning tenant
+	tenantID string
 
 	logger *slog.Logger
 }
@@ -34,13 +43,23 @@ type OAuthService struct {
 type StateInfo struct {
 	CreatedAt time.Time
 	ExpiresAt time.Time
+	// CodeVerifier is the PKCE verifier bound to this state (empty when PKCE is disabled)
+	CodeVerifier string
+	// ReturnTo is where the browser is sent after a successful callback
+	ReturnTo string
+	// Tenant is the tenant that issued the state
+	Tenant string
 }
 
 // TokenStore represents the structure for persisting tokens
 type TokenStore struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at,omitempty"`
+	Scopes           []string  `json:"scopes,omitempty"`
+	AuthorizedBy     *User     `json:"authorized_by,omitempty"`
+	AuthorizedAt     time.Time `json:"authorized_at,omitempty"`
 }
 
 type tokenResponse struct {
@@ -64,11 +83,16 @@ func NewOAuthService(cfg *Config, logger *slog.Logger, tokenFilePath ...string)
 
 	service := &OAuthService{
 		config:        cfg,
-		stateStore:    make(map[string]StateInfo),
+		stateStore:    NewMemoryStateStore(),
 		tokenFilePath: filePath,
 		logger:        logger,
 	}
 
+	// Signed states can be verified by any replica without shared storage
+	if cfg.OAuthStateMode == StateModeSigned {
+		service.stateSigner = newStateSigner(cfg.OAuthStateSecret)
+	}
+
 	// Try to load existing tokens on startup
 	if err := service.LoadTokens(); err != nil {
 		service.logger.Warn("failed to load existing tokens", "error", err)
@@ -86,11 +110,24 @@ func (o *OAuthService) GetAuthorizationURL(state string) string {
 	params.Set("redirect_uri", o.config.ZoomRedirectURI)
 	params.Set("state", state)
 
+	// Attach the PKCE challenge for the verifier bound to this state
+	info, err := o.lookupState(state)
+	if err != nil {
+		o.logger.Debug("no stored information for OAuth state", "error", err)
+	}
+	if info.CodeVerifier != "" {
+		params.Set("code_challenge", pkceChallenge(info.CodeVerifier))
+		params.Set("code_challenge_method", "S256")
+	}
+
 	return baseURL + "?" + params.Encode()
 }
 
-// ExchangeCodeForToken exchanges authorization code for access token
-func (o *OAuthService) ExchangeCodeForToken(code string) error {
+// ExchangeCodeForToken exchanges authorization code for access token.
+// The optional code verifier is sent when the state was issued with PKCE.
+// Without one the code is exchanged as before PKCE, which works for codes
+// obtained without a code_challenge; Zoom rejects codes that were.
+func (o *OAuthService) ExchangeCodeForToken(code string, codeVerifier ...string) error {
 	if code == "" {
 		return fmt.Errorf("authorization code is required")
 	}
@@ -105,6 +142,9 @@ func (o *OAuthService) ExchangeCodeForToken(code string) error {
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
 	data.Set("redirect_uri", o.config.ZoomRedirectURI)
+	if len(codeVerifier) > 0 && codeVerifier[0] != "" {
+		data.Set("code_verifier", codeVerifier[0])
+	}
 
 	// Create the request
 	req, err := http.NewRequest("POST", tokenURL, bytes.NewBufferString(data.Encode()))
@@ -144,13 +184,31 @@ func (o *OAuthService) ExchangeCodeForToken(code string) error {
 		return fmt.Errorf("no access token received in response")
 	}
 
+	// Record who authorized the app; a failed lookup doesn't invalidate the tokens
+	authorizer, err := fetchAuthorizer(tokenResp.AccessToken)
+	if err != nil {
+		o.logger.Warn("failed to look up authorizing user", "error", err)
+	}
+
+	scopes := parseScopes(tokenResp.Scope)
+	if missing := missingScopes(scopes, o.config.RequiredScopes); len(missing) > 0 {
+		o.logger.Warn("authorization is missing required scopes", "missing", missing, "granted", scopes)
+	}
+
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
 	// Store the user tokens
 	o.userAccessToken = tokenResp.AccessToken
 	o.userRefreshToken = tokenResp.RefreshToken
 	o.userExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
+	o.refreshExpiresAt = time.Now().Add(zoomRefreshTokenLifetime)
+	o.grantedScopes = scopes
+	o.authorizedBy = authorizer
+	o.authorizedAt = time.Now()
 
 	// Auto-save tokens to file
-	if err := o.SaveTokens(); err != nil {
+	if err := o.saveTokens(); err != nil {
 		// Log the error but don't fail the token exchange
 		o.logger.Warn("failed to save tokens to file", "error", err)
 	}
@@ -160,6 +218,9 @@ func (o *OAuthService) ExchangeCodeForToken(code string) error {
 
 // GetUserAccessToken returns a valid user access token (for authorization code flow)
 func (o *OAuthService) GetUserAccessToken() (string, error) {
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
 	// Check if we have a valid user token
 	if o.userAccessToken != "" && time.Now().Before(o.userExpiresAt) {
 		return o.userAccessToken, nil
@@ -173,7 +234,7 @@ func (o *OAuthService) GetUserAccessToken() (string, error) {
 	return "", fmt.Errorf("no valid user access token available, authorization required")
 }
 
-// refreshUserToken refreshes the user access token using the refresh token
+// refreshUserToken refreshes the user access token using the refresh token (must be called with tokenMutex held)
 func (o *OAuthService) refreshUserToken() (string, error) {
 	tokenURL := "https://zoom.us/oauth/token"
 
@@ -216,11 +277,15 @@ func (o *OAuthService) refreshUserToken() (string, error) {
 	o.userAccessToken = tokenResp.AccessToken
 	if tokenResp.RefreshToken != "" {
 		o.userRefreshToken = tokenResp.RefreshToken
+		o.refreshExpiresAt = time.Now().Add(zoomRefreshTokenLifetime)
+	}
+	if scopes := parseScopes(tokenResp.Scope); len(scopes) > 0 {
+		o.grantedScopes = scopes
 	}
 	o.userExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
 
 	// Auto-save refreshed tokens
-	if err := o.SaveTokens(); err != nil {
+	if err := o.saveTokens(); err != nil {
 		// Log the error but don't fail the token refresh
 		o.logger.Warn("failed to save refreshed tokens to file", "error", err)
 	}
@@ -230,6 +295,41 @@ func (o *OAuthService) refreshUserToken() (string, error) {
 
 // GenerateState generates a secure random state parameter and stores it
 func (o *OAuthService) GenerateState() (string, error) {
+	return o.GenerateStateWithReturnTo("")
+}
+
+// GenerateStateWithReturnTo generates a state parameter that redirects the browser to
+// returnTo after a successful callback. returnTo must pass the configured allowlist;
+// when empty the configured default is used.
+func (o *OAuthService) GenerateStateWithReturnTo(returnTo string) (string, error) {
+	if returnTo == "" {
+		returnTo = o.config.OAuthReturnTo
+	}
+	if err := validateReturnTo(returnTo, o.config.OAuthReturnToAllowlist); err != nil {
+		return "", err
+	}
+
+	info := StateInfo{
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(oauthStateTTL),
+		ReturnTo:  returnTo,
+		Tenant:    o.tenantID,
+	}
+
+	// Bind a PKCE verifier to the state unless disabled
+	if !o.config.DisablePKCE {
+		verifier, err := generateCodeVerifier()
+		if err != nil {
+			return "", err
+		}
+		info.CodeVerifier = verifier
+	}
+
+	if o.stateSigner != nil {
+		state, _, err := o.stateSigner.issue(info)
+		return state, err
+	}
+
 	// Generate 32 bytes of random data
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
@@ -239,16 +339,8 @@ func (o *OAuthService) GenerateState() (string, error) {
 	state := base64.URLEncoding.EncodeToString(b)
 
 	// Store the state with expiration
-	o.stateMutex.Lock()
-	defer o.stateMutex.Unlock()
-
-	// Clean up expired states
-	o.cleanupExpiredStates()
-
-	// Store new state (expires in 10 minutes)
-	o.stateStore[state] = StateInfo{
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+	if err := o.stateStore.Save(state, info); err != nil {
+		return "", fmt.Errorf("failed to store state: %w", err)
 	}
 
 	return state, nil
@@ -256,41 +348,88 @@ func (o *OAuthService) GenerateState() (string, error) {
 
 // ValidateState validates and consumes a state parameter
 func (o *OAuthService) ValidateState(state string) error {
+	_, err := o.ConsumeState(state)
+	return err
+}
+
+// ConsumeState validates and consumes a state parameter, returning the information bound to it
+func (o *OAuthService) ConsumeState(state string) (StateInfo, error) {
 	if state == "" {
-		return fmt.Errorf("state parameter is required")
+		return StateInfo{}, fmt.Errorf("state parameter is required")
 	}
 
-	o.stateMutex.Lock()
-	defer o.stateMutex.Unlock()
-
-	// Clean up expired states
-	o.cleanupExpiredStates()
-
-	stateInfo, exists := o.stateStore[state]
-	if !exists {
-		return fmt.Errorf("invalid or expired state parameter")
+	var stateInfo StateInfo
+	if o.stateSigner != nil {
+		info, err := o.stateSigner.consume(state, !o.config.DisablePKCE)
+		if err != nil {
+			return StateInfo{}, err
+		}
+		stateInfo = info
+	} else {
+		// Consume the state (remove it so it can't be reused)
+		info, exists, err := o.stateStore.Take(state)
+		if err != nil {
+			return StateInfo{}, fmt.Errorf("failed to load state: %w", err)
+		}
+		if !exists {
+			return StateInfo{}, fmt.Errorf("invalid or expired state parameter")
+		}
+		stateInfo = info
 	}
 
 	// Check if state has expired
 	if time.Now().After(stateInfo.ExpiresAt) {
-		delete(o.stateStore, state)
-		return fmt.Errorf("state parameter has expired")
+		return StateInfo{}, fmt.Errorf("state parameter has expired")
 	}
 
-	// Consume the state (remove it so it can't be reused)
-	delete(o.stateStore, state)
+	// Tokens must be bound to the tenant that started the flow
+	if stateInfo.Tenant != o.tenantID {
+		return StateInfo{}, fmt.Errorf("state parameter was issued for another tenant")
+	}
 
-	return nil
+	return stateInfo, nil
 }
 
-// cleanupExpiredStates removes expired state entries (must be called with mutex held)
-func (o *OAuthService) cleanupExpiredStates() {
-	now := time.Now()
-	for state, info := range o.stateStore {
-		if now.After(info.ExpiresAt) {
-			delete(o.stateStore, state)
-		}
+// lookupState returns the information bound to a state without consuming it
+func (o *OAuthService) lookupState(state string) (StateInfo, error) {
+	if o.stateSigner != nil {
+		info, _, err := o.stateSigner.decode(state, !o.config.DisablePKCE)
+		return info, err
+	}
+
+	info, exists, err := o.stateStore.Get(state)
+	if err != nil {
+		return StateInfo{}, err
+	}
+	if !exists {
+		return StateInfo{}, fmt.Errorf("unknown state parameter")
+	}
+	return info, nil
+}
+
+// SetStateStore replaces the store used for OAuth state parameters. In signed
+// mode the store records issued nonces instead, so a shared store extends replay
+// protection across replicas.
+func (o *OAuthService) SetStateStore(store StateStore) {
+	o.stateStore = store
+	if o.stateSigner != nil {
+		o.stateSigner.nonces = store
+	}
+}
+
+// generateCodeVerifier creates a random PKCE code verifier (RFC 7636, 43 characters)
+func generateCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate code verifier: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge derives the S256 code challenge for a verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
 // IsUserAuthorized checks if we have a valid user access token
@@ -306,14 +445,26 @@ func (o *OAuthService) GetConfig() *Config {
 
 // SaveTokens saves tokens to the configured file path
 func (o *OAuthService) SaveTokens() error {
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
+	return o.saveTokens()
+}
+
+// saveTokens writes the current tokens to disk (must be called with tokenMutex held)
+func (o *OAuthService) saveTokens() error {
 	if o.tokenFilePath == "" {
 		return fmt.Errorf("no token file path configured")
 	}
 
 	store := TokenStore{
-		AccessToken:  o.userAccessToken,
-		RefreshToken: o.userRefreshToken,
-		ExpiresAt:    o.userExpiresAt,
+		AccessToken:      o.userAccessToken,
+		RefreshToken:     o.userRefreshToken,
+		ExpiresAt:        o.userExpiresAt,
+		RefreshExpiresAt: o.refreshExpiresAt,
+		Scopes:           o.grantedScopes,
+		AuthorizedBy:     o.authorizedBy,
+		AuthorizedAt:     o.authorizedAt,
 	}
 
 	// Ensure directory exists
@@ -353,22 +504,127 @@ func (o *OAuthService) LoadTokens() error {
 		return fmt.Errorf("failed to unmarshal tokens: %w", err)
 	}
 
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
+	// The authorization details describe the stored session, so they are only
+	// restored along with its tokens
+	restoreSession := func() {
+		o.refreshExpiresAt = store.RefreshExpiresAt
+		o.grantedScopes = store.Scopes
+		o.authorizedBy = store.AuthorizedBy
+		o.authorizedAt = store.AuthorizedAt
+	}
+
 	// Only load tokens if they haven't expired
 	if time.Now().Before(store.ExpiresAt) {
 		o.userAccessToken = store.AccessToken
 		o.userRefreshToken = store.RefreshToken
 		o.userExpiresAt = store.ExpiresAt
+		restoreSession()
 	} else if store.RefreshToken != "" {
-		// Token expired but we have a refresh token, attempt to refresh
+		// Token expired but we have a refresh token, attempt to refresh. The
+		// session is restored first so the refreshed tokens are saved with it.
 		o.userRefreshToken = store.RefreshToken
+		restoreSession()
 		if _, err := o.refreshUserToken(); err != nil {
 			o.logger.Warn("failed to refresh expired token during load", "error", err)
+			o.resetSession()
 		}
 	}
 
 	return nil
 }
 
+// Revoke revokes the user authorization at Zoom and clears the stored tokens.
+// Local tokens are cleared even if the remote revocation fails.
+func (o *OAuthService) Revoke() error {
+	o.tokenMutex.Lock()
+	token := o.userAccessToken
+	if token == "" {
+		token = o.userRefreshToken
+	}
+	o.tokenMutex.Unlock()
+
+	var revokeErr error
+	if token != "" {
+		revokeErr = o.revokeToken(token)
+	}
+
+	if err := o.ClearTokens(); err != nil {
+		return err
+	}
+
+	if revokeErr != nil {
+		return fmt.Errorf("tokens cleared locally but Zoom revocation failed: %w", revokeErr)
+	}
+	return nil
+}
+
+// revokeToken calls Zoom's revoke endpoint for the given token
+func (o *OAuthService) revokeToken(token string) error {
+	revokeURL := "https://zoom.us/oauth/revoke"
+
+	// Prepare form data
+	data := url.Values{}
+	data.Set("token", token)
+
+	req, err := http.NewRequest("POST", revokeURL, bytes.NewBufferString(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revoke request: %w", err)
+	}
+
+	req.SetBasicAuth(o.config.ZoomClientID, o.config.ZoomClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute revoke request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var responseBody bytes.Buffer
+	if _, err := responseBody.ReadFrom(resp.Body); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OAuth revoke failed with status %d: %s", resp.StatusCode, responseBody.String())
+	}
+
+	return nil
+}
+
+// ClearTokens drops the user tokens from memory and removes the token file
+func (o *OAuthService) ClearTokens() error {
+	o.tokenMutex.Lock()
+	defer o.tokenMutex.Unlock()
+
+	o.resetSession()
+
+	if o.tokenFilePath == "" {
+		return nil
+	}
+	if err := os.Remove(o.tokenFilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove token file: %w", err)
+	}
+
+	return nil
+}
+
+// resetSession forgets the user tokens and authorization details (must be called
+// with tokenMutex held)
+func (o *OAuthService) resetSession() {
+	o.userAccessToken = ""
+	o.userRefreshToken = ""
+	o.userExpiresAt = time.Time{}
+	o.refreshExpiresAt = time.Time{}
+	o.grantedScopes = nil
+	o.authorizedBy = nil
+	o.authorizedAt = time.Time{}
+}
+
 // GetTokenFilePath returns the configured token file path
 func (o *OAuthService) GetTokenFilePath() string {
 	return o.tokenFilePath
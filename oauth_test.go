@@ -0,0 +1,128 @@
+package zoomalert
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func newTestOAuthService(t *testing.T, configure func(*Config)) *OAuthService {
+	t.Helper()
+	config := DefaultConfig()
+	config.ZoomClientID = "client"
+	config.ZoomRedirectURI = "https://alerts.example.com/oauth/callback"
+	configure(config)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewOAuthService(config, logger, filepath.Join(t.TempDir(), "tokens.json"))
+}
+
+// The challenge is the unpadded base64url SHA-256 of the verifier (S256)
+func TestPKCEChallenge(t *testing.T) {
+	const (
+		verifier  = "dBjftJeZ4CVP-mJ92ZVYz0Sbls3JgNiLqDAx7wi1mgIcmtjw"
+		challenge = "D8r5i8zmG6CdEa1SB5Yhf5_zDZ9NjAqCqE4fm4XA0_U"
+	)
+	if got := pkceChallenge(verifier); got != challenge {
+		t.Fatalf("pkceChallenge = %q, want %q", got, challenge)
+	}
+
+	generated, err := generateCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC 7636 requires 43 to 128 characters
+	if len(generated) != 43 {
+		t.Fatalf("verifier %q has %d characters, want 43", generated, len(generated))
+	}
+}
+
+func TestOAuthPKCEFlow(t *testing.T) {
+	modes := map[string]func(*Config){
+		"memory state": func(c *Config) {},
+		"signed state": func(c *Config) {
+			c.OAuthStateMode = StateModeSigned
+			c.OAuthStateSecret = "state-secret"
+		},
+	}
+	for name, configure := range modes {
+		t.Run(name, func(t *testing.T) {
+			o := newTestOAuthService(t, configure)
+
+			state, err := o.GenerateState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			authURL, err := url.Parse(o.GetAuthorizationURL(state))
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := authURL.Query()
+			if query.Get("state") != state {
+				t.Fatalf("state = %q, want %q", query.Get("state"), state)
+			}
+			if query.Get("code_challenge_method") != "S256" {
+				t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+			}
+
+			// The callback recovers the verifier whose challenge was sent to Zoom
+			info, err := o.ConsumeState(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.CodeVerifier == "" {
+				t.Fatal("no verifier bound to the state")
+			}
+			if got := pkceChallenge(info.CodeVerifier); got != query.Get("code_challenge") {
+				t.Fatalf("verifier challenge = %q, authorization URL sent %q", got, query.Get("code_challenge"))
+			}
+
+			if _, err := o.ConsumeState(state); err == nil {
+				t.Fatal("state accepted twice")
+			}
+		})
+
+		t.Run(name+" without PKCE", func(t *testing.T) {
+			o := newTestOAuthService(t, func(c *Config) {
+				configure(c)
+				c.DisablePKCE = true
+			})
+
+			state, err := o.GenerateState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			authURL, err := url.Parse(o.GetAuthorizationURL(state))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if challenge := authURL.Query().Get("code_challenge"); challenge != "" {
+				t.Fatalf("code_challenge = %q, want none", challenge)
+			}
+			info, err := o.ConsumeState(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.CodeVerifier != "" {
+				t.Fatalf("verifier = %q, want none", info.CodeVerifier)
+			}
+		})
+	}
+}
+
+func TestOAuthStateTenant(t *testing.T) {
+	issuer := newTestOAuthService(t, func(c *Config) {})
+	issuer.tenantID = "acme"
+	state, err := issuer.GenerateState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestOAuthService(t, func(c *Config) {})
+	other.tenantID = "globex"
+	other.SetStateStore(issuer.stateStore)
+	if _, err := other.ConsumeState(state); err == nil {
+		t.Fatal("state of another tenant accepted")
+	}
+}
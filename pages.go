@@ -0,0 +1,84 @@
+package zoomalert
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// pageTemplates holds the HTML pages shown to admins during the OAuth flow
+var pageTemplates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// oauthSuccessPage is the data for templates/oauth_success.html
+type oauthSuccessPage struct {
+	Status   AuthStatus
+	ReturnTo string
+}
+
+// oauthErrorPage is the data for templates/oauth_error.html
+type oauthErrorPage struct {
+	Message  string
+	Detail   string
+	RetryURL string
+}
+
+// renderOAuthSuccess writes the OAuth success page
+func renderOAuthSuccess(w io.Writer, page oauthSuccessPage) error {
+	return pageTemplates.ExecuteTemplate(w, "oauth_success.html", page)
+}
+
+// renderOAuthError writes the OAuth failure page
+func renderOAuthError(w io.Writer, page oauthErrorPage) error {
+	return pageTemplates.ExecuteTemplate(w, "oauth_error.html", page)
+}
+
+// validateReturnTo checks a post-authorization redirect target against the allowlist.
+// Relative paths on this host are always allowed; absolute URLs must share scheme
+// and host with an allowlist entry and sit under its path.
+func validateReturnTo(returnTo string, allowlist []string) error {
+	if returnTo == "" {
+		return nil
+	}
+	if strings.ContainsAny(returnTo, "\\\r\n") {
+		return fmt.Errorf("return_to contains invalid characters")
+	}
+
+	target, err := url.Parse(returnTo)
+	if err != nil {
+		return fmt.Errorf("invalid return_to URL: %w", err)
+	}
+
+	// Same-host relative path (but not a scheme-relative "//host" URL)
+	if target.Scheme == "" && target.Host == "" && strings.HasPrefix(returnTo, "/") && !strings.HasPrefix(returnTo, "//") {
+		return nil
+	}
+
+	if target.Scheme != "https" && target.Scheme != "http" {
+		return fmt.Errorf("return_to must be an http(s) URL")
+	}
+	if target.User != nil {
+		return fmt.Errorf("return_to must not contain credentials")
+	}
+
+	for _, entry := range allowlist {
+		allowed, err := url.Parse(entry)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(allowed.Scheme, target.Scheme) || !strings.EqualFold(allowed.Host, target.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if prefix == "" || target.Path == prefix || strings.HasPrefix(target.Path, prefix+"/") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("return_to %q is not in the allowlist", returnTo)
+}
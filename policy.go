@@ -0,0 +1,166 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrPolicyViolation is returned when a client's policy forbids a send
+var ErrPolicyViolation = errors.New("forbidden by policy")
+
+// DefaultPolicyClient is the policy key applied to clients without their own policy
+const DefaultPolicyClient = "*"
+
+// Policy restricts what an API client may send. Empty fields are unrestricted.
+type Policy struct {
+	// Recipients are glob patterns for recipient emails, e.g. "*@company.com"
+	Recipients []string `json:"recipients,omitempty"`
+	// Channels are glob patterns for channel JIDs
+	Channels []string `json:"channels,omitempty"`
+	// Templates lists the templates the client may render
+	Templates []string `json:"templates,omitempty"`
+	// MaxLevel is the highest alert level the client may send
+	MaxLevel AlertLevel `json:"max_level,omitempty"`
+	// MaxRecipients caps the recipients of a single request
+	MaxRecipients int `json:"max_recipients,omitempty"`
+}
+
+// PolicyViolation describes which policy rule rejected a send
+type PolicyViolation struct {
+	Client string
+	// Rule is the violated policy field (recipients, channels, templates, max_level,
+	// max_recipients) or "client" when the client has no policy
+	Rule   string
+	Detail string
+}
+
+// Error implements error
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("client %q: %s: %s", v.Client, v.Rule, v.Detail)
+}
+
+// Unwrap makes errors.Is(err, ErrPolicyViolation) hold
+func (v *PolicyViolation) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// policySet resolves and enforces client policies
+type policySet struct {
+	policies map[string]Policy
+}
+
+// newPolicySet returns nil when no policies are configured (everything allowed)
+func newPolicySet(policies map[string]Policy) *policySet {
+	if len(policies) == 0 {
+		return nil
+	}
+	for client, policy := range policies {
+		// Normalize the level so Rank comparisons work for e.g. "critical"
+		if level, err := ParseAlertLevel(string(policy.MaxLevel)); err == nil {
+			policy.MaxLevel = level
+			policies[client] = policy
+		}
+	}
+	return &policySet{policies: policies}
+}
+
+// check verifies a send against the client's policy. Sends without a client identity
+// (in-process calls without AsClient) are not restricted.
+func (p *policySet) check(client string, tos []Recipient, msg message) error {
+	if p == nil || client == "" {
+		return nil
+	}
+
+	policy, ok := p.policies[client]
+	if !ok {
+		if policy, ok = p.policies[DefaultPolicyClient]; !ok {
+			return &PolicyViolation{Client: client, Rule: "client", Detail: "no policy configured for this client"}
+		}
+	}
+
+	if policy.MaxRecipients > 0 && len(tos) > policy.MaxRecipients {
+		return &PolicyViolation{Client: client, Rule: "max_recipients",
+			Detail: fmt.Sprintf("%d recipients exceed the limit of %d", len(tos), policy.MaxRecipients)}
+	}
+	for _, to := range tos {
+		if to.Email != "" && len(policy.Recipients) > 0 && !matchAny(policy.Recipients, strings.ToLower(to.Email)) {
+			return &PolicyViolation{Client: client, Rule: "recipients",
+				Detail: fmt.Sprintf("recipient %s is not allowed", to.Email)}
+		}
+		if to.ChannelJID != "" && len(policy.Channels) > 0 && !matchAny(policy.Channels, to.ChannelJID) {
+			return &PolicyViolation{Client: client, Rule: "channels",
+				Detail: fmt.Sprintf("channel %s is not allowed", to.ChannelJID)}
+		}
+	}
+	if msg.template != "" && len(policy.Templates) > 0 && !matchAny(policy.Templates, msg.template) {
+		return &PolicyViolation{Client: client, Rule: "templates",
+			Detail: fmt.Sprintf("template %s is not allowed", msg.template)}
+	}
+	if policy.MaxLevel != "" {
+		// Plain and rich content carries no level but can look like any severity,
+		// so it counts as the highest level
+		level := msg.level
+		if level.Rank() == 0 {
+			level = AlertLevelCritical
+		}
+		if level.Rank() > policy.MaxLevel.Rank() {
+			if msg.level.Rank() == 0 {
+				return &PolicyViolation{Client: client, Rule: "max_level",
+					Detail: fmt.Sprintf("content without a level counts as %s and exceeds %s", level, policy.MaxLevel)}
+			}
+			return &PolicyViolation{Client: client, Rule: "max_level",
+				Detail: fmt.Sprintf("level %s exceeds %s", msg.level, policy.MaxLevel)}
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether value matches one of the glob patterns
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePolicies checks glob syntax and levels
+func validatePolicies(policies map[string]Policy) error {
+	for client, policy := range policies {
+		for _, patterns := range [][]string{policy.Recipients, policy.Channels, policy.Templates} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("policy %q: invalid pattern %q: %w", client, pattern, err)
+				}
+			}
+		}
+		if policy.MaxLevel != "" {
+			if _, err := ParseAlertLevel(string(policy.MaxLevel)); err != nil {
+				return fmt.Errorf("policy %q: %w", client, err)
+			}
+		}
+		if policy.MaxRecipients < 0 {
+			return fmt.Errorf("policy %q: max_recipients must not be negative", client)
+		}
+	}
+	return nil
+}
+
+// LoadPolicyFile reads client policies from a JSON object keyed by client ID
+func LoadPolicyFile(filename string) (map[string]Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies file: %w", err)
+	}
+
+	var policies map[string]Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse policies file: %w", err)
+	}
+	return policies, nil
+}
@@ -0,0 +1,43 @@
+package zoomalert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyMaxLevel(t *testing.T) {
+	policies := newPolicySet(map[string]Policy{
+		"ci":                {MaxLevel: "warning"},
+		DefaultPolicyClient: {},
+	})
+	to := []Recipient{{Email: "a@example.com"}}
+
+	tests := []struct {
+		name    string
+		client  string
+		level   AlertLevel
+		allowed bool
+	}{
+		{"below the cap", "ci", AlertLevelInfo, true},
+		{"at the cap", "ci", AlertLevelWarning, true},
+		{"above the cap", "ci", AlertLevelCritical, false},
+		{"no level counts as critical", "ci", "", false},
+		{"unknown level counts as critical", "ci", "SEVERE", false},
+		{"no cap", "other", "", true},
+		{"trusted in-process send", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policies.check(tt.client, to, message{level: tt.level})
+			if tt.allowed && err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if !tt.allowed {
+				var violation *PolicyViolation
+				if !errors.As(err, &violation) || violation.Rule != "max_level" {
+					t.Fatalf("err = %v, want a max_level violation", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package zoomalert
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a send exceeds a client or recipient rate limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// defaultOutboundRateLimit keeps chat message sends under Zoom's per-account limit
+// for the chat message API (Medium: 20 requests per second on Pro accounts)
+var defaultOutboundRateLimit = RateLimit{Rate: 20, Burst: 20}
+
+// maxIdleBuckets bounds limiter memory; full buckets are pruned beyond it
+const maxIdleBuckets = 10000
+
+// RateLimit is a token bucket: Rate tokens per second, holding at most Burst
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// ParseRateLimit parses "N/s", "N/m" or "N/h" with a burst of N
+func ParseRateLimit(s string) (RateLimit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must look like 30/m", s)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid count", s)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return RateLimit{}, fmt.Errorf("rate limit %q: unit must be s, m or h", s)
+	}
+
+	return RateLimit{
+		Rate:  n / period.Seconds(),
+		Burst: int(math.Max(1, math.Ceil(n))),
+	}, nil
+}
+
+// RateLimitError reports which limit rejected a send and when to retry
+type RateLimitError struct {
+	// Scope is "client" or "recipient"
+	Scope      string
+	Key        string
+	RetryAfter time.Duration
+}
+
+// Error implements error
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded for %s, retry after %s", e.Scope, e.Key, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap makes errors.Is(err, ErrRateLimited) hold
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// tokenBucket is the state of one rate limit key
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter holds a token bucket per key
+type rateLimiter struct {
+	limit   RateLimit
+	buckets map[string]*tokenBucket
+	mutex   sync.Mutex
+}
+
+// newRateLimiter returns nil (no limit) when the rate is not positive
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &rateLimiter{
+		limit:   limit,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// bucket refills and returns the bucket for key (must be called with mutex held)
+func (l *rateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+		return b
+	}
+
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+	b.updated = now
+	return b
+}
+
+// prune drops buckets that have refilled completely (must be called with mutex held)
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// allow takes a token for key if one is available, otherwise it reports how long
+// until the next token
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b := l.bucket(key, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, l.delay(b.tokens)
+}
+
+// refund returns a token taken by allow, e.g. when a later check of the same
+// request failed
+func (l *rateLimiter) refund(key string, now time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b := l.bucket(key, now)
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+1)
+}
+
+// wait takes a token for key, blocking until it is available
+func (l *rateLimiter) wait(key string) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	b := l.bucket(key, time.Now())
+	// Reserve the token now; a negative balance queues later callers behind us
+	b.tokens--
+	delay := l.delay(b.tokens + 1)
+	l.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// delay is the time until a bucket holding tokens reaches one token
+func (l *rateLimiter) delay(tokens float64) time.Duration {
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / l.limit.Rate * float64(time.Second))
+}
+
+// loadRateLimitsFromEnv reads the ALERT_RATE_LIMIT_* and ZOOM_OUTBOUND_RATE_LIMIT settings
+func loadRateLimitsFromEnv(config *Config) {
+	for _, env := range []struct {
+		name  string
+		limit *RateLimit
+	}{
+		{"ALERT_RATE_LIMIT_CLIENT", &config.ClientRateLimit},
+		{"ALERT_RATE_LIMIT_RECIPIENT", &config.RecipientRateLimit},
+		{"ZOOM_OUTBOUND_RATE_LIMIT", &config.OutboundRateLimit},
+	} {
+		if val := os.Getenv(env.name); val != "" {
+			if limit, err := ParseRateLimit(val); err == nil {
+				*env.limit = limit
+			} else {
+				slog.Warn("Ignoring invalid rate limit", "variable", env.name, "value", val, "error", err)
+			}
+		}
+		if val := os.Getenv(env.name + "_BURST"); val != "" {
+			if burst, err := strconv.Atoi(val); err == nil {
+				env.limit.Burst = burst
+			} else {
+				slog.Warn("Ignoring invalid rate limit burst", "variable", env.name+"_BURST", "value", val, "error", err)
+			}
+		}
+	}
+
+	if val := os.Getenv("ALERT_RATE_LIMIT_BYPASS_LEVEL"); val != "" {
+		if strings.EqualFold(val, "none") {
+			config.RateLimitBypassLevel = ""
+		} else if level, err := ParseAlertLevel(val); err == nil {
+			config.RateLimitBypassLevel = level
+		} else {
+			slog.Warn("Ignoring invalid ALERT_RATE_LIMIT_BYPASS_LEVEL", "value", val, "error", err)
+		}
+	}
+}
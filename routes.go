@@ -0,0 +1,127 @@
+package zoomalert
+
+import (
+	"net/http"
+)
+
+// apiPrefix is where StartHTTPServer and the Gin adapter mount the module's routes
+const apiPrefix = "/api/v1"
+
+// routeGroup says which registration function exposes a route
+type routeGroup int
+
+const (
+	routeGroupOAuth routeGroup = iota
+	routeGroupAlert
+	routeGroupIntegration
+)
+
+// routeAccess says which credentials a route requires when authentication is configured
+type routeAccess int
+
+const (
+	// accessAlert routes always require credentials
+	accessAlert routeAccess = iota
+	// accessOAuth routes require credentials when Config.AuthProtectOAuth is set
+	accessOAuth
+	// accessHealth routes require credentials when Config.AuthProtectHealth is set
+	accessHealth
+	// accessPublic routes are called by Zoom or other services and verify requests themselves
+	accessPublic
+)
+
+// route is a single module endpoint, shared by the net/http mux and the Gin adapter
+type route struct {
+	method  string
+	path    string
+	group   routeGroup
+	access  routeAccess
+	handler http.HandlerFunc
+	// tenantScoped routes are also served under /tenants/{tenant}
+	tenantScoped bool
+}
+
+// routes returns the module's route table. Paths are relative to the mount point.
+func (h *AlertHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/health", routeGroupOAuth, accessHealth, h.healthCheck, false},
+		{http.MethodGet, "/auth/status", routeGroupOAuth, accessOAuth, h.getAuthStatus, true},
+		{http.MethodGet, "/oauth/callback", routeGroupOAuth, accessPublic, h.oauthCallback, true},
+		{http.MethodGet, "/oauth/authorize", routeGroupOAuth, accessOAuth, h.oauthAuthorize, true},
+		{http.MethodGet, "/oauth/start", routeGroupOAuth, accessOAuth, h.OAuthStart, true},
+		{http.MethodPost, "/oauth/revoke", routeGroupOAuth, accessOAuth, h.OAuthRevoke, true},
+		{http.MethodPost, "/zoom/events", routeGroupOAuth, accessPublic, h.ZoomEvents, true},
+		{http.MethodPost, "/alert", routeGroupAlert, accessAlert, h.sendAlert, true},
+		{http.MethodPost, "/alert/rich", routeGroupAlert, accessAlert, h.SendRichAlert, true},
+		{http.MethodPost, "/alert/severity", routeGroupAlert, accessAlert, h.SendSeverityAlert, true},
+		{http.MethodPost, "/alert/templated", routeGroupAlert, accessAlert, h.SendTemplatedAlert, true},
+		{http.MethodGet, "/jobs/{id}", routeGroupAlert, accessAlert, h.GetJob, false},
+		{http.MethodPost, "/integrations/alertmanager", routeGroupIntegration, accessAlert, h.AlertmanagerWebhook, true},
+		{http.MethodPost, "/integrations/grafana", routeGroupIntegration, accessAlert, h.GrafanaWebhook, true},
+		{http.MethodPost, "/integrations/github", routeGroupIntegration, accessPublic, h.GitHubWebhook, true},
+		{http.MethodPost, "/integrations/gitlab", routeGroupIntegration, accessPublic, h.GitLabWebhook, true},
+		{http.MethodPost, "/integrations/sentry", routeGroupIntegration, accessPublic, h.SentryWebhook, true},
+		{http.MethodPost, "/events", routeGroupIntegration, accessAlert, h.CloudEvents, true},
+		{http.MethodPost, "/integrations/sns", routeGroupIntegration, accessPublic, h.SNSWebhook, true},
+		{http.MethodPost, "/hooks/{token}", routeGroupIntegration, accessPublic, h.SlackWebhook, false},
+		{http.MethodPost, "/teams/{token}", routeGroupIntegration, accessPublic, h.TeamsWebhook, false},
+		{http.MethodPost, "/webhooks/{name}", routeGroupIntegration, accessPublic, h.MappedWebhook, true},
+	}
+}
+
+// expandRoutes adds the /tenants/{tenant} variant of every tenant-scoped route
+func expandRoutes(routes []route) []route {
+	expanded := make([]route, 0, 2*len(routes))
+	for _, rt := range routes {
+		expanded = append(expanded, rt)
+		if rt.tenantScoped {
+			tenantRoute := rt
+			tenantRoute.path = "/tenants/{tenant}" + rt.path
+			expanded = append(expanded, tenantRoute)
+		}
+	}
+	return expanded
+}
+
+// routes returns the module's expanded route table with authentication applied
+func (m *ZoomAlertModule) routes() []route {
+	h := newDispatchAlertHandler(m.dispatcher)
+	h.integrations = m.integrations
+	h.sns = m.sns
+	h.webhooks = m.webhooks
+	routes := expandRoutes(h.routes())
+	for i, rt := range routes {
+		if m.requiresAuth(rt.access) {
+			routes[i].handler = m.auth.middleware(rt.handler)
+		}
+	}
+	return routes
+}
+
+// requiresAuth reports whether routes with the given access need credentials
+func (m *ZoomAlertModule) requiresAuth(access routeAccess) bool {
+	if m.auth == nil {
+		return false
+	}
+	switch access {
+	case accessAlert:
+		return true
+	case accessOAuth:
+		return m.config.AuthProtectOAuth
+	case accessHealth:
+		return m.config.AuthProtectHealth
+	}
+	return false
+}
+
+// Handler returns every module route as a plain http.Handler. Paths are unprefixed
+// (e.g. /alert, /oauth/callback), so mount it under any prefix with http.StripPrefix:
+//
+//	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", module.Handler()))
+func (m *ZoomAlertModule) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range m.routes() {
+		mux.HandleFunc(rt.method+" "+rt.path, rt.handler)
+	}
+	return mux
+}
@@ -0,0 +1,207 @@
+package zoomalert
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testHookToken = "slack-hook-token-0123456789"
+
+// newTestModule builds a module with the Slack receiver enabled and no credentials
+func newTestModule(t *testing.T) *ZoomAlertModule {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.ZoomAccountID = "account"
+	config.ZoomClientID = "client"
+	config.ZoomClientSecret = "secret"
+	config.TokenFilePath = filepath.Join(t.TempDir(), "tokens.json")
+	config.Integrations.Slack = &SlackConfig{
+		Hooks: map[string]Destination{testHookToken: {Channels: []string{"sre@conference.xmpp.zoom.us"}}},
+	}
+	module, err := NewZoomAlertModule(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { module.Shutdown() })
+	return module
+}
+
+// newTestGinRouter mounts the module's routes on a fresh Gin engine
+func newTestGinRouter(m *ZoomAlertModule) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	m.RegisterRoutes(router)
+	return router
+}
+
+// serve sends one request to handler and returns the recorded response
+func serve(handler http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerAndGinRoutesMatch(t *testing.T) {
+	m := newTestModule(t)
+
+	var want []string
+	for _, rt := range expandRoutes(newDispatchAlertHandler(m.dispatcher).routes()) {
+		want = append(want, rt.method+" "+apiPrefix+ginPath(rt.path))
+	}
+	var got []string
+	for _, info := range newTestGinRouter(m).Routes() {
+		got = append(got, info.Method+" "+info.Path)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Gin routes differ from the route table\ngot:\n%s\nwant:\n%s",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHandlerAndGinResponsesMatch(t *testing.T) {
+	m := newTestModule(t)
+	handler := m.Handler()
+	router := newTestGinRouter(m)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"health", http.MethodGet, "/health", "", "", http.StatusOK},
+		{"auth status", http.MethodGet, "/auth/status", "", "", http.StatusOK},
+		{"default tenant", http.MethodGet, "/tenants/default/auth/status", "", "", http.StatusOK},
+		{"unknown tenant", http.MethodGet, "/tenants/acme/auth/status", "", "", http.StatusNotFound},
+		{"unknown tenant alert", http.MethodPost, "/tenants/acme/alert", "application/json", `{"email":"a@example.com","message":"hi"}`, http.StatusNotFound},
+		{"alert without message", http.MethodPost, "/alert", "application/json", `{"email":"a@example.com"}`, http.StatusBadRequest},
+		{"malformed alert", http.MethodPost, "/alert", "application/json", `{`, http.StatusBadRequest},
+		{"oversized alert", http.MethodPost, "/alert", "application/json", `{"message":"` + strings.Repeat("a", maxSignedBodyBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		{"unknown job", http.MethodGet, "/jobs/0123456789abcdef", "", "", http.StatusNotFound},
+		{"unknown hook token", http.MethodPost, "/hooks/not-a-configured-token", "application/json", `{"text":"hi"}`, http.StatusNotFound},
+		{"known hook token", http.MethodPost, "/hooks/" + testHookToken, "application/json", `not json`, http.StatusBadRequest},
+		{"unconfigured integration", http.MethodPost, "/integrations/alertmanager", "application/json", `{}`, http.StatusNotFound},
+		{"callback without code", http.MethodGet, "/oauth/callback?state=abc", "application/json", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			std := serve(handler, tt.method, tt.path, tt.contentType, tt.body)
+			viaGin := serve(router, tt.method, apiPrefix+tt.path, tt.contentType, tt.body)
+
+			if std.Code != tt.status {
+				t.Errorf("Handler status = %d, want %d: %s", std.Code, tt.status, std.Body)
+			}
+			if viaGin.Code != std.Code {
+				t.Errorf("Gin status = %d, Handler status = %d", viaGin.Code, std.Code)
+			}
+			if !bytes.Equal(viaGin.Body.Bytes(), std.Body.Bytes()) {
+				t.Errorf("bodies differ\nGin:     %s\nHandler: %s", viaGin.Body, std.Body)
+			}
+			if got, want := viaGin.Header().Get("Content-Type"), std.Header().Get("Content-Type"); got != want {
+				t.Errorf("Gin Content-Type = %q, Handler Content-Type = %q", got, want)
+			}
+		})
+	}
+}
+
+func TestGinHandlerPathValues(t *testing.T) {
+	const pattern = "/tenants/{tenant}/jobs/{id}/hooks/{token}"
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jsonObject{
+			"tenant": r.PathValue("tenant"),
+			"id":     r.PathValue("id"),
+			"token":  r.PathValue("token"),
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(http.MethodGet+" "+pattern, echo)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(ginPath(pattern), ginHandler(echo))
+
+	path := "/tenants/acme/jobs/42/hooks/secret-token"
+	std := serve(mux, http.MethodGet, path, "", "")
+	viaGin := serve(router, http.MethodGet, path, "", "")
+
+	want := `{"id":"42","tenant":"acme","token":"secret-token"}`
+	for name, rec := range map[string]*httptest.ResponseRecorder{"Handler": std, "Gin": viaGin} {
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s status = %d", name, rec.Code)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("%s path values = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestGinPath(t *testing.T) {
+	tests := map[string]string{
+		"/health":                  "/health",
+		"/jobs/{id}":               "/jobs/:id",
+		"/tenants/{tenant}/alert":  "/tenants/:tenant/alert",
+		"/hooks/{token}":           "/hooks/:token",
+		"/tenants/{tenant}/{name}": "/tenants/:tenant/:name",
+	}
+	for path, want := range tests {
+		if got := ginPath(path); got != want {
+			t.Errorf("ginPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// The Gin-signature AlertHandler methods answer like the routes they wrap
+func TestAlertHandlerGinMethods(t *testing.T) {
+	m := newTestModule(t)
+	handler := m.Handler()
+	h := newDispatchAlertHandler(m.dispatcher)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/health", h.HealthCheck)
+	router.GET("/auth/status", h.GetAuthStatus)
+	router.GET("/oauth/authorize", h.OAuthAuthorize)
+	router.GET("/oauth/callback", h.OAuthCallback)
+	router.POST("/alert", h.SendAlert)
+
+	tests := []struct {
+		method, path, body string
+		// compareBody is false where the response carries a fresh OAuth state
+		compareBody bool
+	}{
+		{http.MethodGet, "/health", "", true},
+		{http.MethodGet, "/auth/status", "", true},
+		{http.MethodGet, "/oauth/authorize", "", false},
+		{http.MethodGet, "/oauth/callback?error=access_denied", "", true},
+		{http.MethodPost, "/alert", `{"email":"a@example.com"}`, true},
+	}
+	for _, tt := range tests {
+		std := serve(handler, tt.method, tt.path, "application/json", tt.body)
+		viaGin := serve(router, tt.method, tt.path, "application/json", tt.body)
+		if viaGin.Code != std.Code {
+			t.Errorf("%s %s: Gin status = %d, Handler status = %d", tt.method, tt.path, viaGin.Code, std.Code)
+		}
+		if tt.compareBody && viaGin.Body.String() != std.Body.String() {
+			t.Errorf("%s %s: bodies differ\nGin:     %s\nHandler: %s", tt.method, tt.path, viaGin.Body, std.Body)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package zoomalert
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Errors returned by the send pipeline, usable with errors.Is
+var (
+	ErrUserNotAuthorized = errors.New("user is not authorized")
+	ErrUnknownTenant     = errors.New("unknown tenant")
+	ErrUnknownBot        = errors.New("unknown bot")
+	ErrUnknownTemplate   = errors.New("unknown template")
+	ErrInvalidMessage    = errors.New("invalid message")
+)
+
+// SendOption customizes a single send
+type SendOption func(*sendOptions)
+
+// sendOptions collects the SendOption values for a send
+type sendOptions struct {
+	tenant string
+	bot    string
+	// client is the authenticated HTTP caller, recorded in audit logs
+	client Client
+	// source keys the client rate limit: the client ID, or the remote address
+	// of unauthenticated HTTP requests
+	source string
+	// idempotencyKey deduplicates retried sends
+	idempotencyKey string
+}
+
+// ForTenant sends through the given tenant instead of the default one
+func ForTenant(id string) SendOption {
+	return func(o *sendOptions) {
+		o.tenant = id
+	}
+}
+
+// ViaBot sends from the named bot instead of the default ZOOM_ROBOT_JID bot
+func ViaBot(name string) SendOption {
+	return func(o *sendOptions) {
+		o.bot = name
+	}
+}
+
+// AsClient enforces the named client's policy on a send, as if it came from that
+// API client over HTTP
+func AsClient(id string) SendOption {
+	return func(o *sendOptions) {
+		o.client = Client{ID: id}
+		o.source = id
+	}
+}
+
+// WithIdempotencyKey makes retries of a send with the same key return the first
+// result instead of sending again
+func WithIdempotencyKey(key string) SendOption {
+	return func(o *sendOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// collectSendOptions applies opts to an empty sendOptions
+func collectSendOptions(opts []SendOption) sendOptions {
+	var o sendOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// dispatcher is the send pipeline shared by the Go API and the HTTP handlers
+type dispatcher struct {
+	tenants   *tenantRegistry
+	templates *TemplateRegistry
+	policies  *policySet
+	limits    sendLimits
+	// idempotency is nil when sends are not deduplicated
+	idempotency *idempotency
+	// jobs runs asynchronous sends; its workers start with the first job
+	jobs     *jobQueue
+	logger   *slog.Logger
+	inflight *sync.WaitGroup
+}
+
+// message is content to deliver plus the attributes policies are checked against
+type message struct {
+	content  ZoomContent
+	level    AlertLevel
+	template string
+}
+
+// send delivers content to a recipient through the tenant and bot selected by o
+func (d *dispatcher) send(to Recipient, content ZoomContent, o sendOptions) (*SendResult, error) {
+	return first(d.deliver([]Recipient{to}, message{content: content}, o))
+}
+
+// sendAlert validates and renders a severity alert, then sends it
+func (d *dispatcher) sendAlert(to Recipient, alert Alert, o sendOptions) (*SendResult, error) {
+	msg, err := alertMessage(alert)
+	if err != nil {
+		return nil, err
+	}
+	return first(d.deliver([]Recipient{to}, msg, o))
+}
+
+// sendTemplate renders a named template with data and sends it
+func (d *dispatcher) sendTemplate(to Recipient, name string, data any, o sendOptions) (*SendResult, error) {
+	msg, err := d.templateMessage(name, data, &o)
+	if err != nil {
+		return nil, err
+	}
+	return first(d.deliver([]Recipient{to}, msg, o))
+}
+
+// alertMessage validates and renders a severity alert
+func alertMessage(alert Alert) (message, error) {
+	if err := alert.Validate(); err != nil {
+		return message{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return message{content: alert.Content(), level: alert.Level}, nil
+}
+
+// templateMessage renders a named template with data. The template's bot is
+// selected in o unless the caller already chose one.
+func (d *dispatcher) templateMessage(name string, data any, o *sendOptions) (message, error) {
+	rendered, err := d.templates.render(name, data)
+	if err != nil {
+		if errors.Is(err, ErrUnknownTemplate) {
+			return message{}, err
+		}
+		return message{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if o.bot == "" {
+		o.bot = rendered.bot
+	}
+	return message{content: rendered.content, level: rendered.level, template: name}, nil
+}
+
+// delivery is a send that passed validation, policy, idempotency and rate limit checks
+type delivery struct {
+	tenant         *Tenant
+	tos            []Recipient
+	msg            message
+	o              sendOptions
+	idempotencyKey string
+	fingerprint    string
+	// replay is set when an idempotency key matched a completed send
+	replay *IdempotencyRecord
+}
+
+// deliver checks the client's policy against every recipient, then sends to each
+// in turn. Per-recipient failures are reported in the results and joined into the error.
+func (d *dispatcher) deliver(tos []Recipient, msg message, o sendOptions) ([]SendResult, error) {
+	dl, err := d.prepare(tos, msg, o)
+	if err != nil {
+		return nil, err
+	}
+	if dl.replay != nil {
+		return dl.replay.replay()
+	}
+	return d.execute(dl)
+}
+
+// prepare runs every check that can reject a send before anything is sent
+func (d *dispatcher) prepare(tos []Recipient, msg message, o sendOptions) (*delivery, error) {
+	if len(tos) == 0 {
+		return nil, fmt.Errorf("%w: email or channel is required", ErrInvalidMessage)
+	}
+	for _, to := range tos {
+		if err := to.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+	}
+
+	tenant, err := d.tenants.get(o.tenant)
+	if err != nil {
+		return nil, err
+	}
+	dl := &delivery{tenant: tenant, tos: tos, msg: msg, o: o}
+
+	if err := d.policies.check(o.client.ID, tos, msg); err != nil {
+		d.logger.Warn("Send rejected by policy", "audit", true, "client", o.client.ID, "error", err)
+		return nil, err
+	}
+
+	if o.idempotencyKey != "" && d.idempotency != nil {
+		// Keys are scoped to the caller so clients cannot collide
+		dl.idempotencyKey = o.source + "|" + o.idempotencyKey
+		dl.fingerprint = requestFingerprint(tenant.ID(), tos, msg, o)
+		record, err := d.idempotency.begin(dl.idempotencyKey, dl.fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			d.logger.Info("Replaying idempotent send", "client", o.client.ID, "idempotency_key", o.idempotencyKey)
+			dl.replay = record
+			return dl, nil
+		}
+	}
+
+	if err := d.limits.check(tenant.ID(), o.source, tos, msg.level); err != nil {
+		d.logger.Warn("Send rejected by rate limit", "audit", true, "client", o.client.ID, "error", err)
+		d.abandon(dl)
+		return nil, err
+	}
+
+	return dl, nil
+}
+
+// execute sends a prepared delivery to each recipient
+func (d *dispatcher) execute(dl *delivery) ([]SendResult, error) {
+	if d.inflight != nil {
+		d.inflight.Add(1)
+		defer d.inflight.Done()
+	}
+
+	tenantID := dl.tenant.ID()
+	results := make([]SendResult, 0, len(dl.tos))
+	var errs []error
+	for _, to := range dl.tos {
+		d.limits.outbound.wait(tenantID)
+		result, err := dl.tenant.send(to, dl.msg.content, dl.o)
+		d.audit(to, tenantID, dl.o, result, err)
+		if err != nil {
+			errs = append(errs, err)
+			results = append(results, SendResult{Recipient: to, Error: err.Error()})
+			continue
+		}
+		results = append(results, *result)
+	}
+
+	sendErr := errors.Join(errs...)
+	if dl.idempotencyKey != "" {
+		if err := d.idempotency.finish(dl.idempotencyKey, dl.fingerprint, results, sendErr); err != nil {
+			d.logger.Error("Failed to record idempotent send", "idempotency_key", dl.o.idempotencyKey, "error", err)
+		}
+	}
+	return results, sendErr
+}
+
+// abandon releases the idempotency key of a delivery that will not be executed
+func (d *dispatcher) abandon(dl *delivery) {
+	if dl.idempotencyKey != "" && dl.replay == nil {
+		d.releaseIdempotencyKey(dl.idempotencyKey)
+	}
+}
+
+// releaseIdempotencyKey forgets a claimed key after a send was rejected
+func (d *dispatcher) releaseIdempotencyKey(key string) {
+	if err := d.idempotency.store.Release(key); err != nil {
+		d.logger.Error("Failed to release idempotency key", "error", err)
+	}
+}
+
+// sendLimits are the inbound and outbound rate limiters of the send pipeline
+type sendLimits struct {
+	client      *rateLimiter
+	recipient   *rateLimiter
+	outbound    *rateLimiter
+	bypassLevel AlertLevel
+}
+
+// check takes a client token and a token per recipient, unless the level bypasses
+// inbound limits. It is all-or-nothing: when any bucket is empty, the tokens
+// already taken for this send are refunded so a rejected send costs no quota.
+func (l sendLimits) check(tenant, source string, tos []Recipient, level AlertLevel) error {
+	if l.bypassLevel != "" && level.Rank() >= l.bypassLevel.Rank() {
+		return nil
+	}
+
+	now := time.Now()
+	if source != "" {
+		if ok, retry := l.client.allow(source, now); !ok {
+			return &RateLimitError{Scope: "client", Key: source, RetryAfter: retry}
+		}
+	}
+	for i, to := range tos {
+		if ok, retry := l.recipient.allow(tenant+"/"+to.String(), now); !ok {
+			for _, taken := range tos[:i] {
+				l.recipient.refund(tenant+"/"+taken.String(), now)
+			}
+			if source != "" {
+				l.client.refund(source, now)
+			}
+			return &RateLimitError{Scope: "recipient", Key: to.String(), RetryAfter: retry}
+		}
+	}
+	return nil
+}
+
+// first adapts deliver's result for single-recipient sends
+func first(results []SendResult, err error) (*SendResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// audit records who sent what to whom, and whether it was delivered
+func (d *dispatcher) audit(to Recipient, tenant string, o sendOptions, result *SendResult, err error) {
+	attrs := []any{
+		"audit", true,
+		"client", o.client.ID,
+		"client_auth", o.client.Method,
+		"recipient", to.String(),
+		"tenant", tenant,
+		"bot", o.bot,
+	}
+	if err != nil {
+		d.logger.Warn("Message send failed", append(attrs, "error", err)...)
+		return
+	}
+	d.logger.Info("Message sent successfully", append(attrs, "message_id", result.MessageID)...)
+}
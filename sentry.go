@@ -0,0 +1,341 @@
+package zoomalert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Sentry integration webhook headers
+const (
+	SentryResourceHeader  = "Sentry-Hook-Resource"
+	SentrySignatureHeader = "Sentry-Hook-Signature"
+)
+
+// SentryConfig verifies Sentry integration webhooks and routes them by project slug
+type SentryConfig struct {
+	// Secret is the integration's client secret
+	Secret string `json:"secret"`
+	// Projects maps Sentry project slugs to destinations
+	Projects map[string]Destination `json:"projects"`
+	// Default receives events of unlisted projects
+	Default *Destination `json:"default,omitempty"`
+}
+
+// destination returns where events of the first routed project go
+func (c *SentryConfig) destination(projects ...string) (Destination, bool) {
+	for _, slug := range projects {
+		if dest, ok := c.Projects[slug]; ok {
+			return dest, true
+		}
+	}
+	if c.Default != nil {
+		return *c.Default, true
+	}
+	return Destination{}, false
+}
+
+// validate checks the secret and every project destination
+func (c *SentryConfig) validate() error {
+	if c.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	for slug, dest := range c.Projects {
+		if err := dest.validate(); err != nil {
+			return fmt.Errorf("project %q: %w", slug, err)
+		}
+	}
+	if c.Default != nil {
+		if err := c.Default.validate(); err != nil {
+			return fmt.Errorf("default destination: %w", err)
+		}
+	}
+	return nil
+}
+
+// sentryProject is the project object of Sentry issue payloads
+type sentryProject struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// sentryWebhook is the envelope of Sentry integration webhooks
+type sentryWebhook struct {
+	Action string          `json:"action"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// sentryIssueData is the data of "issue" webhooks
+type sentryIssueData struct {
+	Issue struct {
+		ShortID   string        `json:"shortId"`
+		Title     string        `json:"title"`
+		Culprit   string        `json:"culprit"`
+		Level     string        `json:"level"`
+		Count     string        `json:"count"`
+		UserCount int           `json:"userCount"`
+		Permalink string        `json:"permalink"`
+		WebURL    string        `json:"web_url"`
+		Project   sentryProject `json:"project"`
+	} `json:"issue"`
+}
+
+// sentryEventAlertData is the data of "event_alert" webhooks (issue alert rules)
+type sentryEventAlertData struct {
+	Event struct {
+		Title       string     `json:"title"`
+		Culprit     string     `json:"culprit"`
+		Level       string     `json:"level"`
+		Environment string     `json:"environment"`
+		URL         string     `json:"url"`
+		WebURL      string     `json:"web_url"`
+		IssueURL    string     `json:"issue_url"`
+		Tags        [][]string `json:"tags"`
+	} `json:"event"`
+	TriggeredRule string `json:"triggered_rule"`
+}
+
+// sentryMetricAlertData is the data of "metric_alert" webhooks
+type sentryMetricAlertData struct {
+	MetricAlert struct {
+		Title     string   `json:"title"`
+		Projects  []string `json:"projects"`
+		AlertRule struct {
+			Name     string   `json:"name"`
+			Projects []string `json:"projects"`
+		} `json:"alert_rule"`
+	} `json:"metric_alert"`
+	DescriptionTitle string `json:"description_title"`
+	DescriptionText  string `json:"description_text"`
+	WebURL           string `json:"web_url"`
+}
+
+// SentryWebhook receives Sentry issue, issue alert and metric alert webhooks
+func (h *AlertHandler) SentryWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.Sentry
+	if cfg == nil {
+		integrationNotConfigured(w, "Sentry")
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	if err := verifySentrySignature(cfg.Secret, r.Header.Get(SentrySignatureHeader), body); err != nil {
+		writeJSON(w, http.StatusUnauthorized, jsonObject{"error": err.Error()})
+		return
+	}
+
+	var hook sentryWebhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid Sentry payload: " + err.Error()})
+		return
+	}
+
+	msg, projects, ok, err := renderSentry(r.Header.Get(SentryResourceHeader), hook)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid Sentry payload: " + err.Error()})
+		return
+	}
+	if !ok {
+		// Installation hooks and issue changes like assignment are acknowledged silently
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	dest, ok := cfg.destination(projects...)
+	if !ok {
+		noDestination(w, fmt.Sprintf("project %q", strings.Join(projects, ",")))
+		return
+	}
+
+	o := integrationSendOptions(r, dest)
+	o.source = "sentry"
+	h.dispatch(w, r, dest.recipients(), msg, o)
+}
+
+// verifySentrySignature checks Sentry-Hook-Signature = hex(HMAC-SHA256(client secret, body))
+func verifySentrySignature(secret, signature string, body []byte) error {
+	if signature == "" {
+		return fmt.Errorf("missing %s header", SentrySignatureHeader)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid Sentry signature")
+	}
+	return nil
+}
+
+// renderSentry renders a webhook and returns the project slugs to route by. It
+// returns false for resources and actions that aren't notified.
+func renderSentry(resource string, hook sentryWebhook) (message, []string, bool, error) {
+	switch resource {
+	case "issue":
+		var data sentryIssueData
+		if err := json.Unmarshal(hook.Data, &data); err != nil {
+			return message{}, nil, false, err
+		}
+		var headline string
+		switch hook.Action {
+		case "created":
+			headline = "New issue"
+		case "unresolved":
+			headline = "Issue regressed"
+		case "resolved":
+			headline = "Issue resolved"
+		default:
+			return message{}, nil, false, nil
+		}
+		issue := data.Issue
+		link := issue.WebURL
+		if link == "" {
+			link = issue.Permalink
+		}
+		fields := []Field{
+			{Key: "Project", Value: issue.Project.Slug},
+			{Key: "Level", Value: issue.Level},
+			{Key: "Events", Value: issue.Count},
+			{Key: "Users", Value: fmt.Sprint(issue.UserCount)},
+		}
+		if issue.ShortID != "" {
+			fields = append(fields, Field{Key: "Issue", Value: issue.ShortID})
+		}
+		msg := renderSentryCard(issue.Title, headline+" · "+issue.Project.Slug, issue.Culprit, issue.Level,
+			hook.Action == "resolved", fields, link)
+		return msg, []string{issue.Project.Slug}, true, nil
+
+	case "event_alert":
+		var data sentryEventAlertData
+		if err := json.Unmarshal(hook.Data, &data); err != nil {
+			return message{}, nil, false, err
+		}
+		event := data.Event
+		slug := sentryProjectFromURL(event.URL)
+		environment := event.Environment
+		if environment == "" {
+			environment = sentryTag(event.Tags, "environment")
+		}
+		fields := []Field{{Key: "Project", Value: slug}}
+		if environment != "" {
+			fields = append(fields, Field{Key: "Environment", Value: environment})
+		}
+		fields = append(fields, Field{Key: "Level", Value: event.Level})
+		if release := sentryTag(event.Tags, "release"); release != "" {
+			fields = append(fields, Field{Key: "Release", Value: release})
+		}
+		link := event.WebURL
+		if link == "" {
+			link = event.IssueURL
+		}
+		msg := renderSentryCard(event.Title, "Alert: "+data.TriggeredRule, event.Culprit, event.Level, false, fields, link)
+		return msg, []string{slug}, true, nil
+
+	case "metric_alert":
+		var data sentryMetricAlertData
+		if err := json.Unmarshal(hook.Data, &data); err != nil {
+			return message{}, nil, false, err
+		}
+		level := ""
+		switch hook.Action {
+		case "critical":
+			level = "fatal"
+		case "warning":
+			level = "warning"
+		case "resolved":
+		default:
+			return message{}, nil, false, nil
+		}
+		alert := data.MetricAlert
+		projects := alert.Projects
+		if len(projects) == 0 {
+			projects = alert.AlertRule.Projects
+		}
+		title := data.DescriptionTitle
+		if title == "" {
+			title = alert.Title
+		}
+		fields := []Field{
+			{Key: "Rule", Value: alert.AlertRule.Name},
+			{Key: "Projects", Value: strings.Join(projects, ", ")},
+		}
+		msg := renderSentryCard(title, "Metric alert "+hook.Action, data.DescriptionText, level,
+			hook.Action == "resolved", fields, data.WebURL)
+		return msg, projects, true, nil
+	}
+	return message{}, nil, false, nil
+}
+
+// renderSentryCard builds the card shared by Sentry issues and alerts
+func renderSentryCard(title, subhead, text, sentryLevel string, resolved bool, fields []Field, link string) message {
+	level := levelFromSeverity(sentryLevel)
+	color := level.Color()
+	if resolved {
+		level, color = AlertLevelInfo, resolvedColor
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:    title,
+			Style:   ZoomStyle{Color: color, Bold: true},
+			SubHead: ZoomSubhead{Text: subhead},
+		},
+		Body: []any{},
+	}
+	if text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: text})
+	}
+
+	var items []Field
+	for _, field := range fields {
+		if field.Value != "" {
+			items = append(items, field)
+		}
+	}
+	if len(items) > 0 {
+		content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: items})
+	}
+	if link != "" {
+		content.Body = append(content.Body, ActionsBlock{
+			Type:  "actions",
+			Items: []Action{LinkAction("View in Sentry", link)},
+		})
+	}
+
+	content.Footer = ZoomFooter{Text: "Sentry"}
+	return message{content: content, level: level}
+}
+
+// sentryProjectFromURL extracts the project slug from an event API URL
+// (.../api/0/projects/{org}/{project}/events/{id}/)
+func sentryProjectFromURL(eventURL string) string {
+	u, err := url.Parse(eventURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, seg := range segments {
+		if seg == "projects" && i+2 < len(segments) {
+			return segments[i+2]
+		}
+	}
+	return ""
+}
+
+// sentryTag returns the value of a tag from an event's [key, value] tag list
+func sentryTag(tags [][]string, key string) string {
+	for _, tag := range tags {
+		if len(tag) == 2 && tag[0] == key {
+			return tag[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,205 @@
+package zoomalert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout bounds how long shutdown waits for in-flight requests and sends
+const defaultShutdownTimeout = 30 * time.Second
+
+// StartHTTPServer builds the router and serves it on Config.Port (or Config.UnixSocket),
+// along with any configured syslog listener, until ctx is cancelled or the process
+// receives SIGTERM/SIGINT. It then stops accepting connections and drains in-flight
+// requests and sends before returning.
+func (m *ZoomAlertModule) StartHTTPServer(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	router := http.NewServeMux()
+	router.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, m.Handler()))
+
+	tlsConfig, err := m.serverTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	listener, err := m.listen()
+	if err != nil {
+		return err
+	}
+	if err := m.StartSyslog(); err != nil {
+		listener.Close()
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           recoverPanics(m, requestLogger(m, router)),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	m.serverMutex.Lock()
+	m.server = server
+	m.serverMutex.Unlock()
+
+	errCh := make(chan error, 1)
+	go func() {
+		m.logger.Info("Starting HTTP server", "address", listener.Addr().String(), "tls", tlsConfig != nil)
+		if tlsConfig != nil {
+			errCh <- server.ServeTLS(listener, m.config.TLSCertFile, m.config.TLSKeyFile)
+		} else {
+			errCh <- server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		// Stop the syslog listener and drain queued sends started alongside the server
+		return errors.Join(fmt.Errorf("HTTP server failed: %w", err), m.Shutdown())
+	case <-ctx.Done():
+		m.logger.Info("Shutdown signal received")
+	}
+
+	return m.Shutdown()
+}
+
+// listen opens the configured Unix socket or TCP port
+func (m *ZoomAlertModule) listen() (net.Listener, error) {
+	if m.config.UnixSocket != "" {
+		// Remove a stale socket left behind by a previous run
+		if err := os.Remove(m.config.UnixSocket); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+		listener, err := net.Listen("unix", m.config.UnixSocket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on unix socket %s: %w", m.config.UnixSocket, err)
+		}
+		return listener, nil
+	}
+
+	listener, err := net.Listen("tcp", ":"+m.config.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %s: %w", m.config.Port, err)
+	}
+	return listener, nil
+}
+
+// serverTLSConfig returns the TLS settings, or nil when TLS is not configured.
+// A client CA file enables mutual TLS.
+func (m *ZoomAlertModule) serverTLSConfig() (*tls.Config, error) {
+	if m.config.TLSCertFile == "" && m.config.TLSKeyFile == "" {
+		return nil, nil
+	}
+	if m.config.TLSCertFile == "" || m.config.TLSKeyFile == "" {
+		return nil, fmt.Errorf("both TLS_CERT_FILE and TLS_KEY_FILE are required for TLS")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if m.config.TLSClientCAFile != "" {
+		caPEM, err := os.ReadFile(m.config.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", m.config.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// waitForSends blocks until in-flight sends finish or ctx is done
+func (m *ZoomAlertModule) waitForSends(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight sends: %w", ctx.Err())
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write notes the implicit 200 header sent with the first body bytes
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestLogger logs each request through the module's logger
+func requestLogger(m *ZoomAlertModule, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		m.logger.Debug("HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start))
+	})
+}
+
+// recoverPanics turns a handler panic into a 500 response instead of a dropped connection.
+// Once the handler has started its response a status can no longer be sent, so the
+// connection is aborted instead.
+func recoverPanics(m *ZoomAlertModule, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				m.logger.Error("Panic while handling request", "path", r.URL.Path, "error", err)
+				if rec.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,40 @@
+package zoomalert
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	m := newTestModule(t)
+
+	t.Run("before the response", func(t *testing.T) {
+		handler := recoverPanics(m, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+		rec := serve(handler, http.MethodGet, "/health", "", "")
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+		}
+	})
+
+	// A started response can't be turned into a 500, so the connection is aborted
+	for name, write := range map[string]func(http.ResponseWriter){
+		"after WriteHeader": func(w http.ResponseWriter) { w.WriteHeader(http.StatusAccepted) },
+		"after Write":       func(w http.ResponseWriter) { w.Write([]byte("partial")) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := recoverPanics(m, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				write(w)
+				panic("boom")
+			}))
+			defer func() {
+				if err := recover(); err != http.ErrAbortHandler {
+					t.Fatalf("recovered %v, want http.ErrAbortHandler", err)
+				}
+			}()
+			serve(handler, http.MethodGet, "/health", "", "")
+			t.Fatal("panic was swallowed")
+		})
+	}
+}
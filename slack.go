@@ -0,0 +1,457 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SlackConfig binds the tokens of Slack-compatible incoming webhook URLs
+// (/hooks/{token}) to destinations
+type SlackConfig struct {
+	Hooks HookTokens `json:"hooks"`
+}
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackElement is a Block Kit element: a button, image or text in a context block
+type slackElement struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"-"`
+	URL      string     `json:"url"`
+	Style    string     `json:"style"`
+	ImageURL string     `json:"image_url"`
+	AltText  string     `json:"alt_text"`
+	// PlainText is set for mrkdwn and plain_text context elements, whose text is a string
+	PlainText string `json:"-"`
+}
+
+// UnmarshalJSON accepts text both as a text object (buttons) and as a string
+// (context elements)
+func (e *slackElement) UnmarshalJSON(data []byte) error {
+	type plain slackElement
+	var raw struct {
+		plain
+		RawText json.RawMessage `json:"text"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = slackElement(raw.plain)
+	if len(raw.RawText) == 0 {
+		return nil
+	}
+	if raw.RawText[0] == '"' {
+		return json.Unmarshal(raw.RawText, &e.PlainText)
+	}
+	e.Text = &slackText{}
+	return json.Unmarshal(raw.RawText, e.Text)
+}
+
+// slackBlock is a Block Kit layout block
+type slackBlock struct {
+	Type      string         `json:"type"`
+	Text      *slackText     `json:"text"`
+	Fields    []slackText    `json:"fields"`
+	Accessory *slackElement  `json:"accessory"`
+	Elements  []slackElement `json:"elements"`
+	ImageURL  string         `json:"image_url"`
+	AltText   string         `json:"alt_text"`
+	Title     *slackText     `json:"title"`
+}
+
+// slackAttachment is a legacy message attachment
+type slackAttachment struct {
+	Color      string `json:"color"`
+	Fallback   string `json:"fallback"`
+	Pretext    string `json:"pretext"`
+	AuthorName string `json:"author_name"`
+	Title      string `json:"title"`
+	TitleLink  string `json:"title_link"`
+	Text       string `json:"text"`
+	Fields     []struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	ImageURL string `json:"image_url"`
+	Footer   string `json:"footer"`
+	Actions  []struct {
+		Type  string `json:"type"`
+		Text  string `json:"text"`
+		URL   string `json:"url"`
+		Style string `json:"style"`
+	} `json:"actions"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// SlackPayload is a Slack incoming webhook message
+type SlackPayload struct {
+	Text        string            `json:"text"`
+	Username    string            `json:"username"`
+	Blocks      []slackBlock      `json:"blocks"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// SlackWebhook accepts Slack incoming webhook payloads on /hooks/{token} and sends
+// them, translated to chatbot content, to the token's destination
+func (h *AlertHandler) SlackWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.Slack
+	if cfg == nil {
+		integrationNotConfigured(w, "Slack")
+		return
+	}
+	dest, ok := cfg.Hooks.destination(r.PathValue("token"))
+	if !ok {
+		// Like Slack, unknown and revoked hooks are indistinguishable
+		writeJSON(w, http.StatusNotFound, jsonObject{"error": "no_service"})
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	// Older integrations post the JSON as a "payload" form field
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, jsonObject{"error": "invalid_payload"})
+			return
+		}
+		body = []byte(values.Get("payload"))
+	}
+
+	var payload SlackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "invalid_payload"})
+		return
+	}
+
+	msg, ok := renderSlack(payload)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "no_text"})
+		return
+	}
+
+	o := integrationSendOptions(r, dest)
+	o.source = "slack"
+	h.dispatch(w, r, dest.recipients(), msg, o)
+}
+
+// slackColors maps Slack's named attachment colors to alert levels
+var slackColors = map[string]AlertLevel{
+	"good":    AlertLevelInfo,
+	"warning": AlertLevelWarning,
+	"danger":  AlertLevelError,
+}
+
+// renderSlack translates a Slack message to chatbot content. It returns false when
+// the payload has nothing to show.
+func renderSlack(p SlackPayload) (message, bool) {
+	level := AlertLevelInfo
+	content := ZoomContent{
+		Head: ZoomHead{
+			Style:   ZoomStyle{Color: level.Color(), Bold: true},
+			SubHead: ZoomSubhead{Text: p.Username},
+		},
+		Body: []any{},
+	}
+
+	blocks := p.Blocks
+	text := p.Text
+	// Without blocks, the first line of text is the header
+	if len(blocks) == 0 && text != "" {
+		head, rest, _ := strings.Cut(text, "\n")
+		content.Head.Text = slackPlainText(head)
+		text = rest
+	} else if len(blocks) > 0 {
+		// With blocks, text is only the notification fallback
+		text = ""
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: slackToMarkdown(text), Markdown: true})
+	}
+
+	content.Body = append(content.Body, slackBlocks(blocks, &content)...)
+
+	for _, att := range p.Attachments {
+		if attLevel, named := slackColors[att.Color]; named {
+			level = maxLevel(level, attLevel)
+		}
+		content.Body = append(content.Body, slackAttachmentBlocks(att, &content)...)
+	}
+
+	if content.Head.Text == "" && len(p.Blocks) > 0 && p.Text != "" {
+		// The notification fallback text heads messages built from blocks
+		head, _, _ := strings.Cut(p.Text, "\n")
+		content.Head.Text = slackPlainText(head)
+	}
+	if content.Head.Text == "" {
+		for _, att := range p.Attachments {
+			if att.Fallback != "" {
+				content.Head.Text = slackPlainText(att.Fallback)
+				break
+			}
+		}
+	}
+	if content.Head.Text == "" && len(content.Body) == 0 {
+		return message{}, false
+	}
+	if content.Head.Text == "" {
+		content.Head.Text = "Notification"
+	}
+
+	// The first attachment's color wins, as in Slack's sidebar
+	content.Head.Style.Color = level.Color()
+	if len(p.Attachments) > 0 {
+		if color := slackColor(p.Attachments[0].Color); color != "" {
+			content.Head.Style.Color = color
+		}
+	}
+	return message{content: content, level: level}, true
+}
+
+// slackBlocks translates Block Kit blocks; the first header block becomes the head
+func slackBlocks(blocks []slackBlock, content *ZoomContent) []any {
+	var body []any
+	for _, block := range blocks {
+		switch block.Type {
+		case "header":
+			if block.Text == nil {
+				continue
+			}
+			if content.Head.Text == "" {
+				content.Head.Text = block.Text.Text
+			} else {
+				body = append(body, Message{Type: "message", Text: "**" + block.Text.Text + "**", Markdown: true})
+			}
+
+		case "section":
+			if block.Text != nil && block.Text.Text != "" {
+				body = append(body, slackTextMessage(*block.Text))
+			}
+			if len(block.Fields) > 0 {
+				fields := make([]Field, 0, len(block.Fields))
+				for _, f := range block.Fields {
+					fields = append(fields, slackField(f.Text))
+				}
+				body = append(body, FieldsBlock{Type: "fields", Items: fields})
+			}
+			if acc := block.Accessory; acc != nil {
+				if action, ok := slackButton(*acc); ok {
+					body = append(body, ActionsBlock{Type: "actions", Items: []Action{action}})
+				} else if acc.Type == "image" && acc.ImageURL != "" {
+					body = append(body, imageBlock(acc.ImageURL, "", acc.AltText))
+				}
+			}
+
+		case "actions":
+			var actions []Action
+			for _, el := range block.Elements {
+				if action, ok := slackButton(el); ok {
+					actions = append(actions, action)
+				}
+			}
+			if len(actions) > 0 {
+				body = append(body, ActionsBlock{Type: "actions", Items: actions})
+			}
+
+		case "context":
+			var parts []string
+			for _, el := range block.Elements {
+				switch {
+				case el.PlainText != "":
+					parts = append(parts, slackToMarkdown(el.PlainText))
+				case el.Text != nil:
+					parts = append(parts, slackToMarkdown(el.Text.Text))
+				}
+			}
+			if len(parts) > 0 {
+				body = append(body, Message{Type: "message", Text: "_" + strings.Join(parts, " · ") + "_", Markdown: true})
+			}
+
+		case "image":
+			if block.ImageURL != "" {
+				title := block.AltText
+				if block.Title != nil {
+					title = block.Title.Text
+				}
+				body = append(body, imageBlock(block.ImageURL, "", title))
+			}
+		}
+		// divider and interactive-only blocks have no chatbot equivalent
+	}
+	return body
+}
+
+// slackAttachmentBlocks translates a legacy attachment
+func slackAttachmentBlocks(att slackAttachment, content *ZoomContent) []any {
+	var body []any
+	if att.Pretext != "" {
+		body = append(body, Message{Type: "message", Text: slackToMarkdown(att.Pretext), Markdown: true})
+	}
+
+	title := att.Title
+	if title != "" {
+		if content.Head.Text == "" {
+			content.Head.Text = slackPlainText(title)
+		} else {
+			body = append(body, Message{Type: "message", Text: "**" + stripSlackMarkup(title) + "**", Markdown: true})
+		}
+	}
+	if att.Text != "" {
+		body = append(body, Message{Type: "message", Text: slackToMarkdown(att.Text), Markdown: true})
+	}
+
+	var fields []Field
+	if att.AuthorName != "" {
+		fields = append(fields, Field{Key: "Author", Value: att.AuthorName})
+	}
+	for _, f := range att.Fields {
+		fields = append(fields, Field{Key: f.Title, Value: stripSlackMarkup(f.Value)})
+	}
+	if len(fields) > 0 {
+		body = append(body, FieldsBlock{Type: "fields", Items: fields})
+	}
+
+	body = append(body, slackBlocks(att.Blocks, content)...)
+
+	if att.ImageURL != "" {
+		body = append(body, imageBlock(att.ImageURL, "", att.Title))
+	}
+
+	var actions []Action
+	if att.TitleLink != "" {
+		actions = append(actions, LinkAction("Open", att.TitleLink))
+	}
+	for _, a := range att.Actions {
+		if a.URL != "" {
+			action := LinkAction(a.Text, a.URL)
+			action.Style = slackButtonStyle(a.Style)
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) > 0 {
+		body = append(body, ActionsBlock{Type: "actions", Items: actions})
+	}
+
+	if att.Footer != "" && content.Footer.Text == "" {
+		content.Footer.Text = stripSlackMarkup(att.Footer)
+	}
+	return body
+}
+
+// slackTextMessage translates a text object
+func slackTextMessage(t slackText) Message {
+	if t.Type == "plain_text" {
+		return Message{Type: "message", Text: t.Text}
+	}
+	return Message{Type: "message", Text: slackToMarkdown(t.Text), Markdown: true}
+}
+
+// slackField splits a section field written as "*Key*\nValue" into a key and value
+func slackField(text string) Field {
+	key, value, found := strings.Cut(text, "\n")
+	if !found {
+		return Field{Key: "", Value: stripSlackMarkup(text)}
+	}
+	key = strings.TrimSuffix(strings.Trim(strings.TrimSpace(key), "*_"), ":")
+	return Field{Key: stripSlackMarkup(key), Value: stripSlackMarkup(value)}
+}
+
+// slackButton translates a link button; buttons without a URL only work in Slack
+func slackButton(el slackElement) (Action, bool) {
+	if el.Type != "button" || el.URL == "" {
+		return Action{}, false
+	}
+	label := el.PlainText
+	if el.Text != nil {
+		label = el.Text.Text
+	}
+	action := LinkAction(label, el.URL)
+	action.Style = slackButtonStyle(el.Style)
+	return action, true
+}
+
+// slackButtonStyle maps Slack button styles to chatbot button styles
+func slackButtonStyle(style string) string {
+	switch style {
+	case "primary":
+		return "Primary"
+	case "danger":
+		return "Danger"
+	}
+	return "Default"
+}
+
+// slackColor converts an attachment color ("good", "danger", "#36a64f") to a hex color
+func slackColor(color string) string {
+	if level, named := slackColors[color]; named {
+		if level == AlertLevelInfo {
+			return resolvedColor
+		}
+		return level.Color()
+	}
+	if strings.HasPrefix(color, "#") {
+		return color
+	}
+	if len(color) == 6 {
+		return "#" + color
+	}
+	return ""
+}
+
+var (
+	// slackLinkPattern matches <url|label>, <url>, <@user>, <#channel|name> and <!here>
+	slackLinkPattern = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]+))?>`)
+	// slackBoldPattern matches Slack's *bold*
+	slackBoldPattern = regexp.MustCompile(`(^|[^*\w])\*([^*\n]+)\*([^*\w]|$)`)
+)
+
+// slackToMarkdown converts Slack mrkdwn to the chatbot's markdown
+func slackToMarkdown(text string) string {
+	text = stripSlackMarkup(text)
+	return slackBoldPattern.ReplaceAllString(text, "$1**$2**$3")
+}
+
+// slackPlainText reduces Slack mrkdwn to plain text for the header, which has no
+// markdown: links become their labels and emphasis markers are dropped
+func slackPlainText(text string) string {
+	text = slackLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := slackLinkPattern.FindStringSubmatch(match)
+		if parts[2] != "" && !strings.HasPrefix(parts[1], "@") && !strings.HasPrefix(parts[1], "#") {
+			return parts[2]
+		}
+		return match
+	})
+	return strings.NewReplacer("*", "", "~", "").Replace(stripSlackMarkup(text))
+}
+
+// stripSlackMarkup resolves Slack's angle-bracket links and mentions and unescapes entities
+func stripSlackMarkup(text string) string {
+	text = slackLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := slackLinkPattern.FindStringSubmatch(match)
+		target, label := parts[1], parts[2]
+		switch {
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return target[:1] + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(target, "!")
+		case label != "":
+			return fmt.Sprintf("[%s](%s)", label, target)
+		}
+		return target
+	})
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text)
+}
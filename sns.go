@@ -0,0 +1,521 @@
+package zoomalert
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SNS message types (x-amz-sns-message-type)
+const (
+	snsTypeNotification             = "Notification"
+	snsTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// snsRequestTimeout bounds certificate downloads and subscription confirmations
+const snsRequestTimeout = 10 * time.Second
+
+// snsMaxMessageAge bounds how old a signed message may be, so a captured message
+// can't be replayed later. SNS retries fall well within it.
+const snsMaxMessageAge = time.Hour
+
+// snsMaxClockSkew tolerates messages timestamped slightly in the future
+const snsMaxClockSkew = 5 * time.Minute
+
+// snsHostPattern matches the SNS endpoints that serve signing certificates and
+// subscription confirmations
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// awsAccountPattern matches AWS account IDs
+var awsAccountPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// SNSConfig routes Amazon SNS notifications by topic ARN. A valid signature only
+// proves that some SNS topic sent a message, so unlisted topics are accepted only
+// from the AWS accounts in Accounts.
+type SNSConfig struct {
+	// Topics maps topic ARNs to destinations. Subscriptions are confirmed for
+	// listed topics and for topics owned by one of Accounts.
+	Topics map[string]Destination `json:"topics"`
+	// Accounts lists the AWS account IDs whose unlisted topics go to Default
+	Accounts []string `json:"accounts,omitempty"`
+	// Default receives notifications of unlisted topics owned by one of Accounts
+	Default *Destination `json:"default,omitempty"`
+	// AlarmLevel is the level of CloudWatch alarms entering ALARM (default ERROR)
+	AlarmLevel AlertLevel `json:"alarm_level,omitempty"`
+}
+
+// destination returns where notifications of a topic go
+func (c *SNSConfig) destination(topicARN string) (Destination, bool) {
+	if dest, ok := c.Topics[topicARN]; ok {
+		return dest, true
+	}
+	if c.Default != nil && c.trustsAccount(arnAccount(topicARN)) {
+		return *c.Default, true
+	}
+	return Destination{}, false
+}
+
+// trustsAccount reports whether account is one of the allowed AWS accounts
+func (c *SNSConfig) trustsAccount(account string) bool {
+	return account != "" && slices.Contains(c.Accounts, account)
+}
+
+// validate checks the alarm level and every topic destination
+func (c *SNSConfig) validate() error {
+	if c.AlarmLevel != "" {
+		if _, err := ParseAlertLevel(string(c.AlarmLevel)); err != nil {
+			return fmt.Errorf("alarm_level: %w", err)
+		}
+	}
+	for arn, dest := range c.Topics {
+		if err := dest.validate(); err != nil {
+			return fmt.Errorf("topic %q: %w", arn, err)
+		}
+	}
+	if c.Default != nil {
+		if err := c.Default.validate(); err != nil {
+			return fmt.Errorf("default destination: %w", err)
+		}
+		// Without an account allowlist, any AWS account could subscribe a topic
+		if len(c.Accounts) == 0 {
+			return fmt.Errorf("default destination requires accounts")
+		}
+	}
+	for _, account := range c.Accounts {
+		if !awsAccountPattern.MatchString(account) {
+			return fmt.Errorf("account %q is not a 12-digit AWS account ID", account)
+		}
+	}
+	return nil
+}
+
+// SNSCertificateFetcher retrieves the certificates SNS messages are signed with.
+// Replace the default with WithSNSCertificateFetcher, e.g. with a local stand-in in tests.
+type SNSCertificateFetcher interface {
+	FetchCertificate(certURL string) (*x509.Certificate, error)
+}
+
+// HTTPSNSCertificateFetcher downloads signing certificates over HTTPS
+type HTTPSNSCertificateFetcher struct {
+	Client *http.Client
+}
+
+// FetchCertificate downloads and parses the PEM certificate at certURL
+func (f HTTPSNSCertificateFetcher) FetchCertificate(certURL string) (*x509.Certificate, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: snsRequestTimeout}
+	}
+
+	resp, err := client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch SNS certificate: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SNS certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("SNS certificate is not PEM encoded")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// snsMessage is an SNS HTTP(S) delivery
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicARN         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// stringToSign builds the canonical string SNS signs for the message type
+func (m *snsMessage) stringToSign() string {
+	fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+	if m.Type == snsTypeNotification {
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", m.Timestamp}, [2]string{"TopicArn", m.TopicARN})
+	} else {
+		fields = append(fields,
+			[2]string{"SubscribeURL", m.SubscribeURL},
+			[2]string{"Timestamp", m.Timestamp},
+			[2]string{"Token", m.Token},
+			[2]string{"TopicArn", m.TopicARN})
+	}
+	fields = append(fields, [2]string{"Type", m.Type})
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String()
+}
+
+// snsVerifier checks SNS signatures, caching signing certificates by URL
+type snsVerifier struct {
+	fetcher SNSCertificateFetcher
+	client  *http.Client
+	certs   map[string]*x509.Certificate
+	mutex   sync.Mutex
+}
+
+// newSNSVerifier creates a verifier using fetcher, or HTTPS downloads when nil
+func newSNSVerifier(fetcher SNSCertificateFetcher) *snsVerifier {
+	client := &http.Client{Timeout: snsRequestTimeout}
+	if fetcher == nil {
+		fetcher = HTTPSNSCertificateFetcher{Client: client}
+	}
+	return &snsVerifier{
+		fetcher: fetcher,
+		client:  client,
+		certs:   make(map[string]*x509.Certificate),
+	}
+}
+
+// verify checks a message's signature against its SNS signing certificate
+func (v *snsVerifier) verify(m *snsMessage) error {
+	if err := validateSNSURL(m.SigningCertURL); err != nil {
+		return fmt.Errorf("signing certificate: %w", err)
+	}
+
+	var hash crypto.Hash
+	switch m.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported SNS signature version %q", m.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid SNS signature encoding: %w", err)
+	}
+
+	cert, err := v.certificate(m.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("SNS certificate has no RSA public key")
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(m.stringToSign()))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(m.stringToSign()))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return fmt.Errorf("invalid SNS signature")
+	}
+	return m.checkFresh(time.Now())
+}
+
+// checkFresh rejects messages whose signed timestamp is outside the accepted window
+func (m *snsMessage) checkFresh(now time.Time) error {
+	sent, err := time.Parse(time.RFC3339, m.Timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid SNS timestamp %q", m.Timestamp)
+	}
+	if age := now.Sub(sent); age > snsMaxMessageAge || age < -snsMaxClockSkew {
+		return fmt.Errorf("SNS message timestamp %s is outside the accepted window", m.Timestamp)
+	}
+	return nil
+}
+
+// certificate returns the cached certificate for certURL, fetching it once
+func (v *snsVerifier) certificate(certURL string) (*x509.Certificate, error) {
+	v.mutex.Lock()
+	cert, ok := v.certs[certURL]
+	v.mutex.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	cert, err := v.fetcher.FetchCertificate(certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mutex.Lock()
+	v.certs[certURL] = cert
+	v.mutex.Unlock()
+	return cert, nil
+}
+
+// confirm visits a subscription's SubscribeURL
+func (v *snsVerifier) confirm(m *snsMessage) error {
+	if err := validateSNSURL(m.SubscribeURL); err != nil {
+		return fmt.Errorf("subscribe URL: %w", err)
+	}
+
+	resp, err := v.client.Get(m.SubscribeURL)
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm SNS subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// validateSNSURL only allows HTTPS URLs on SNS's own hosts, so a forged message
+// can't make the module fetch or trust arbitrary URLs
+func validateSNSURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || !snsHostPattern.MatchString(u.Hostname()) {
+		return fmt.Errorf("%q is not an SNS HTTPS URL", raw)
+	}
+	return nil
+}
+
+// SNSWebhook receives Amazon SNS HTTP(S) deliveries: it confirms subscriptions
+// and forwards notifications, rendering CloudWatch alarms
+func (h *AlertHandler) SNSWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.SNS
+	if cfg == nil || h.sns == nil {
+		integrationNotConfigured(w, "SNS")
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	var m snsMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid SNS message: " + err.Error()})
+		return
+	}
+	if err := h.sns.verify(&m); err != nil {
+		writeJSON(w, http.StatusUnauthorized, jsonObject{"error": err.Error()})
+		return
+	}
+
+	dest, routed := cfg.destination(m.TopicARN)
+
+	switch m.Type {
+	case snsTypeSubscriptionConfirmation:
+		if !routed {
+			noDestination(w, fmt.Sprintf("topic %q", m.TopicARN))
+			return
+		}
+		if err := h.sns.confirm(&m); err != nil {
+			slog.Error("SNS subscription confirmation failed", "topic", m.TopicARN, "error", err)
+			writeJSON(w, http.StatusBadGateway, jsonObject{"error": err.Error()})
+			return
+		}
+		slog.Info("Confirmed SNS subscription", "topic", m.TopicARN)
+		writeJSON(w, http.StatusOK, jsonObject{"message": "Subscription confirmed"})
+
+	case snsTypeUnsubscribeConfirmation:
+		slog.Info("SNS subscription removed", "topic", m.TopicARN)
+		w.WriteHeader(http.StatusNoContent)
+
+	case snsTypeNotification:
+		if !routed {
+			noDestination(w, fmt.Sprintf("topic %q", m.TopicARN))
+			return
+		}
+		o := integrationSendOptions(r, dest)
+		o.source = "sns"
+		if o.idempotencyKey == "" {
+			// SNS retries a delivery with the same MessageId
+			o.idempotencyKey = m.MessageID
+		}
+		h.dispatch(w, r, dest.recipients(), renderSNS(&m, cfg.AlarmLevel), o)
+
+	default:
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": fmt.Sprintf("unknown SNS message type %q", m.Type)})
+	}
+}
+
+// cloudWatchAlarm is the JSON message of a CloudWatch alarm notification
+type cloudWatchAlarm struct {
+	AlarmName        string `json:"AlarmName"`
+	AlarmDescription string `json:"AlarmDescription"`
+	AWSAccountID     string `json:"AWSAccountId"`
+	NewStateValue    string `json:"NewStateValue"`
+	NewStateReason   string `json:"NewStateReason"`
+	OldStateValue    string `json:"OldStateValue"`
+	StateChangeTime  string `json:"StateChangeTime"`
+	Region           string `json:"Region"`
+	AlarmARN         string `json:"AlarmArn"`
+	Trigger          struct {
+		MetricName         string  `json:"MetricName"`
+		Namespace          string  `json:"Namespace"`
+		Statistic          string  `json:"Statistic"`
+		ComparisonOperator string  `json:"ComparisonOperator"`
+		Threshold          float64 `json:"Threshold"`
+		Period             int     `json:"Period"`
+		EvaluationPeriods  int     `json:"EvaluationPeriods"`
+		Dimensions         []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"Dimensions"`
+	} `json:"Trigger"`
+}
+
+// renderSNS renders CloudWatch alarms as alerts and other notifications as text
+func renderSNS(m *snsMessage, alarmLevel AlertLevel) message {
+	var alarm cloudWatchAlarm
+	if json.Unmarshal([]byte(m.Message), &alarm) == nil && alarm.AlarmName != "" && alarm.NewStateValue != "" {
+		return renderCloudWatchAlarm(alarm, alarmLevel)
+	}
+
+	title := m.Subject
+	if title == "" {
+		title = "SNS notification"
+	}
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:    title,
+			Style:   ZoomStyle{Color: AlertLevelInfo.Color(), Bold: true},
+			SubHead: ZoomSubhead{Text: m.TopicARN},
+		},
+		Body:   []any{Message{Type: "message", Text: m.Message}},
+		Footer: ZoomFooter{Text: "Amazon SNS"},
+	}
+	return message{content: content, level: AlertLevelInfo}
+}
+
+// renderCloudWatchAlarm renders an alarm state change
+func renderCloudWatchAlarm(alarm cloudWatchAlarm, alarmLevel AlertLevel) message {
+	if alarmLevel == "" {
+		alarmLevel = AlertLevelError
+	}
+
+	level, color := AlertLevelInfo, resolvedColor
+	switch alarm.NewStateValue {
+	case "ALARM":
+		level = alarmLevel
+		color = level.Color()
+	case "INSUFFICIENT_DATA":
+		level = AlertLevelWarning
+		color = level.Color()
+	}
+
+	content := ZoomContent{
+		Head: ZoomHead{
+			Text:  fmt.Sprintf("%s: %s", alarm.NewStateValue, alarm.AlarmName),
+			Style: ZoomStyle{Color: color, Bold: true},
+			SubHead: ZoomSubhead{
+				Text: fmt.Sprintf("CloudWatch alarm · %s → %s", alarm.OldStateValue, alarm.NewStateValue),
+			},
+		},
+		Body: []any{},
+	}
+
+	text := alarm.NewStateReason
+	if alarm.AlarmDescription != "" {
+		text = alarm.AlarmDescription + "\n" + text
+	}
+	if text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: text})
+	}
+
+	trigger := alarm.Trigger
+	fields := []Field{}
+	if trigger.MetricName != "" {
+		fields = append(fields, Field{Key: "Metric", Value: trigger.Namespace + " " + trigger.MetricName})
+		fields = append(fields, Field{
+			Key:   "Condition",
+			Value: fmt.Sprintf("%s %s %s", trigger.Statistic, cloudWatchOperator(trigger.ComparisonOperator), formatMetricValue(trigger.Threshold)),
+		})
+	}
+	for _, dim := range trigger.Dimensions {
+		fields = append(fields, Field{Key: dim.Name, Value: dim.Value})
+	}
+	if alarm.Region != "" {
+		fields = append(fields, Field{Key: "Region", Value: alarm.Region})
+	}
+	if alarm.AWSAccountID != "" {
+		fields = append(fields, Field{Key: "Account", Value: alarm.AWSAccountID})
+	}
+	if len(fields) > 0 {
+		content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: fields})
+	}
+
+	if region := arnRegion(alarm.AlarmARN); region != "" {
+		link := fmt.Sprintf("https://console.aws.amazon.com/cloudwatch/home?region=%s#alarmsV2:alarm/%s",
+			region, url.PathEscape(alarm.AlarmName))
+		content.Body = append(content.Body, ActionsBlock{
+			Type:  "actions",
+			Items: []Action{LinkAction("View alarm", link)},
+		})
+	}
+
+	content.Footer = ZoomFooter{Text: "Amazon CloudWatch · " + alarm.StateChangeTime}
+	return message{content: content, level: level}
+}
+
+// cloudWatchOperator abbreviates CloudWatch comparison operators
+func cloudWatchOperator(op string) string {
+	switch op {
+	case "GreaterThanOrEqualToThreshold":
+		return ">="
+	case "GreaterThanThreshold":
+		return ">"
+	case "LessThanThreshold":
+		return "<"
+	case "LessThanOrEqualToThreshold":
+		return "<="
+	}
+	return op
+}
+
+// arnRegion returns the region of an ARN (arn:partition:service:region:account:resource)
+func arnRegion(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// arnAccount returns the account of an ARN (arn:partition:service:region:account:resource)
+func arnAccount(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
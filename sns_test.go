@@ -0,0 +1,276 @@
+package zoomalert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSNSCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+
+// stubSNSCertificates is a local SNSCertificateFetcher serving one self-signed certificate
+type stubSNSCertificates struct {
+	cert    *x509.Certificate
+	fetched []string
+}
+
+func (s *stubSNSCertificates) FetchCertificate(certURL string) (*x509.Certificate, error) {
+	s.fetched = append(s.fetched, certURL)
+	if certURL != testSNSCertURL {
+		return nil, fmt.Errorf("unexpected certificate URL %q", certURL)
+	}
+	return s.cert, nil
+}
+
+// newTestSNSSigner returns a key and a fetcher serving its self-signed certificate
+func newTestSNSSigner(t *testing.T) (*rsa.PrivateKey, *stubSNSCertificates) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, &stubSNSCertificates{cert: cert}
+}
+
+// signSNS signs m the way SNS does for its signature version
+func signSNS(t *testing.T, key *rsa.PrivateKey, m *snsMessage) {
+	t.Helper()
+
+	m.SigningCertURL = testSNSCertURL
+	var (
+		hash   crypto.Hash
+		digest []byte
+	)
+	switch m.SignatureVersion {
+	case "1":
+		sum := sha1.Sum([]byte(m.stringToSign()))
+		hash, digest = crypto.SHA1, sum[:]
+	default:
+		m.SignatureVersion = "2"
+		sum := sha256.Sum256([]byte(m.stringToSign()))
+		hash, digest = crypto.SHA256, sum[:]
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Signature = base64.StdEncoding.EncodeToString(signature)
+}
+
+func testSNSNotification() *snsMessage {
+	return &snsMessage{
+		Type:      snsTypeNotification,
+		MessageID: "0d7e8b1c-1111-2222-3333-444455556666",
+		TopicARN:  "arn:aws:sns:us-east-1:123456789012:prod-alarms",
+		Subject:   "Disk almost full",
+		Message:   "db-1 is at 95%",
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func TestSNSVerifierSignature(t *testing.T) {
+	key, certs := newTestSNSSigner(t)
+	verifier := newSNSVerifier(certs)
+
+	for _, version := range []string{"1", "2"} {
+		t.Run("v"+version, func(t *testing.T) {
+			m := testSNSNotification()
+			m.SignatureVersion = version
+			signSNS(t, key, m)
+
+			if err := verifier.verify(m); err != nil {
+				t.Fatalf("valid signature rejected: %v", err)
+			}
+
+			tampered := *m
+			tampered.Message = "db-1 is fine"
+			if err := verifier.verify(&tampered); err == nil {
+				t.Fatal("tampered message accepted")
+			}
+		})
+	}
+
+	if len(certs.fetched) != 1 {
+		t.Errorf("certificate fetched %d times, want once", len(certs.fetched))
+	}
+}
+
+func TestSNSMessageFreshness(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		timestamp string
+		wantErr   bool
+	}{
+		{"current", "2026-10-18T12:00:00.000Z", false},
+		{"delayed retry", "2026-10-18T11:15:00.000Z", false},
+		{"small clock skew", "2026-10-18T12:02:00.000Z", false},
+		{"older than an hour", "2026-10-18T10:59:59.000Z", true},
+		{"far in the future", "2026-10-18T12:30:00.000Z", true},
+		{"unparseable", "yesterday", true},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &snsMessage{Timestamp: tt.timestamp}
+			if err := m.checkFresh(now); (err != nil) != tt.wantErr {
+				t.Fatalf("checkFresh(%q) error = %v, want error %v", tt.timestamp, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSNSVerifierRejectsForeignCertificateURL(t *testing.T) {
+	key, certs := newTestSNSSigner(t)
+	m := testSNSNotification()
+	signSNS(t, key, m)
+	m.SigningCertURL = "https://attacker.example.com/cert.pem"
+
+	if err := newSNSVerifier(certs).verify(m); err == nil {
+		t.Fatal("certificate URL outside SNS accepted")
+	}
+	if len(certs.fetched) != 0 {
+		t.Errorf("fetched %v, want no downloads", certs.fetched)
+	}
+}
+
+func TestSNSWebhook(t *testing.T) {
+	key, certs := newTestSNSSigner(t)
+
+	config := DefaultConfig()
+	config.ZoomAccountID = "account"
+	config.ZoomClientID = "client"
+	config.ZoomClientSecret = "secret"
+	config.TokenFilePath = filepath.Join(t.TempDir(), "tokens.json")
+	config.Integrations.SNS = &SNSConfig{
+		Topics: map[string]Destination{
+			"arn:aws:sns:us-east-1:123456789012:prod-alarms": {Channels: []string{"sre@conference.xmpp.zoom.us"}},
+		},
+		Accounts: []string{"123456789012"},
+		Default:  &Destination{Channels: []string{"ops@conference.xmpp.zoom.us"}},
+	}
+	module, err := NewZoomAlertModule(config, WithSNSCertificateFetcher(certs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer module.Shutdown()
+	handler := module.Handler()
+
+	post := func(m *snsMessage) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest(http.MethodPost, "/integrations/sns", bytes.NewReader(body))
+		req.Header.Set("x-amz-sns-message-type", m.Type)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("tampered signature", func(t *testing.T) {
+		m := testSNSNotification()
+		signSNS(t, key, m)
+		m.Subject = "All clear"
+		if rec := post(m); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+		}
+	})
+
+	// A validly signed message captured earlier can't be replayed
+	t.Run("stale notification", func(t *testing.T) {
+		m := testSNSNotification()
+		m.Timestamp = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+		signSNS(t, key, m)
+		if rec := post(m); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+		}
+	})
+
+	// A topic of another AWS account is never confirmed, even with a default destination
+	t.Run("foreign topic subscription", func(t *testing.T) {
+		m := &snsMessage{
+			Type:         snsTypeSubscriptionConfirmation,
+			MessageID:    "subscribe-1",
+			Token:        "token",
+			TopicARN:     "arn:aws:sns:us-east-1:999999999999:attacker",
+			Message:      "You have chosen to subscribe to the topic",
+			SubscribeURL: "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=token",
+			Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		}
+		signSNS(t, key, m)
+		if rec := post(m); rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+		}
+	})
+
+	t.Run("foreign topic notification", func(t *testing.T) {
+		m := testSNSNotification()
+		m.TopicARN = "arn:aws:sns:us-east-1:999999999999:attacker"
+		signSNS(t, key, m)
+		if rec := post(m); rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+		}
+	})
+}
+
+func TestSNSConfigDestination(t *testing.T) {
+	listed := Destination{Channels: []string{"listed@conference.xmpp.zoom.us"}}
+	fallback := Destination{Channels: []string{"default@conference.xmpp.zoom.us"}}
+	cfg := &SNSConfig{
+		Topics:   map[string]Destination{"arn:aws:sns:us-east-1:999999999999:listed": listed},
+		Accounts: []string{"123456789012"},
+		Default:  &fallback,
+	}
+
+	tests := []struct {
+		topic  string
+		want   *Destination
+		routed bool
+	}{
+		{"arn:aws:sns:us-east-1:999999999999:listed", &listed, true},
+		{"arn:aws:sns:eu-west-1:123456789012:other", &fallback, true},
+		{"arn:aws:sns:eu-west-1:999999999999:other", nil, false},
+		{"not-an-arn", nil, false},
+	}
+	for _, tt := range tests {
+		dest, routed := cfg.destination(tt.topic)
+		if routed != tt.routed {
+			t.Errorf("destination(%q) routed = %v, want %v", tt.topic, routed, tt.routed)
+			continue
+		}
+		if tt.want != nil && dest.Channels[0] != tt.want.Channels[0] {
+			t.Errorf("destination(%q) = %v, want %v", tt.topic, dest.Channels, tt.want.Channels)
+		}
+	}
+
+	if err := (&SNSConfig{Default: &fallback}).validate(); err == nil {
+		t.Error("default destination without accounts accepted")
+	}
+}
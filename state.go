@@ -0,0 +1,242 @@
+package zoomalert
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth state modes selectable via Config.OAuthStateMode
+const (
+	// StateModeMemory keeps issued states in a StateStore (in-memory unless replaced)
+	StateModeMemory = "memory"
+	// StateModeSigned issues self-contained HMAC-signed states that any replica can verify
+	StateModeSigned = "signed"
+)
+
+// oauthStateTTL is how long an issued state parameter stays valid
+const oauthStateTTL = 10 * time.Minute
+
+// StateStore persists OAuth state parameters between the authorize request and the callback.
+// Implementations backed by a shared database allow callbacks to land on any replica.
+type StateStore interface {
+	// Save stores the information bound to a state parameter
+	Save(state string, info StateInfo) error
+	// Get returns the information bound to a state parameter and whether it exists
+	Get(state string) (StateInfo, bool, error)
+	// Take atomically returns and removes a state parameter so it cannot be reused
+	Take(state string) (StateInfo, bool, error)
+}
+
+// MemoryStateStore is the default process-local StateStore
+type MemoryStateStore struct {
+	states map[string]StateInfo
+	mutex  sync.Mutex
+}
+
+// NewMemoryStateStore creates an empty in-memory state store
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: make(map[string]StateInfo),
+	}
+}
+
+// Save stores the state and clears out expired entries
+func (s *MemoryStateStore) Save(state string, info StateInfo) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanupExpired()
+	s.states[state] = info
+	return nil
+}
+
+// Get returns the information bound to a state
+func (s *MemoryStateStore) Get(state string) (StateInfo, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanupExpired()
+	info, exists := s.states[state]
+	return info, exists, nil
+}
+
+// Take returns and removes a state
+func (s *MemoryStateStore) Take(state string) (StateInfo, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanupExpired()
+	info, exists := s.states[state]
+	delete(s.states, state)
+	return info, exists, nil
+}
+
+// cleanupExpired removes expired state entries (must be called with mutex held)
+func (s *MemoryStateStore) cleanupExpired() {
+	now := time.Now()
+	for state, info := range s.states {
+		if now.After(info.ExpiresAt) {
+			delete(s.states, state)
+		}
+	}
+}
+
+// signedStatePayload is the signed portion of a stateless state parameter
+type signedStatePayload struct {
+	Nonce     string `json:"n"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ReturnTo  string `json:"rt,omitempty"`
+	Tenant    string `json:"t,omitempty"`
+}
+
+// nonceKeyPrefix namespaces signed-state nonces recorded in a shared StateStore
+const nonceKeyPrefix = "nonce:"
+
+// stateSigner issues and verifies HMAC-signed, expiring state parameters.
+// Without a shared store, used nonces are remembered in process memory until they
+// expire, which prevents replay on a single replica only: another replica would
+// accept the same state once more. With a shared store, every issued nonce is
+// recorded there and taken atomically on use, so a state is redeemed at most once
+// across all replicas.
+type stateSigner struct {
+	secret     []byte
+	usedNonces map[string]time.Time
+	nonces     StateStore
+	mutex      sync.Mutex
+}
+
+// newStateSigner creates a signer using the given shared secret
+func newStateSigner(secret string) *stateSigner {
+	return &stateSigner{
+		secret:     []byte(secret),
+		usedNonces: make(map[string]time.Time),
+	}
+}
+
+// issue creates a signed state for info. The PKCE verifier is derived from the
+// nonce with the secret so it never travels inside the state itself.
+func (s *stateSigner) issue(info StateInfo) (string, StateInfo, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", StateInfo{}, fmt.Errorf("failed to generate state nonce: %w", err)
+	}
+
+	payload := signedStatePayload{
+		Nonce:     base64.RawURLEncoding.EncodeToString(b),
+		IssuedAt:  info.CreatedAt.Unix(),
+		ExpiresAt: info.ExpiresAt.Unix(),
+		ReturnTo:  info.ReturnTo,
+		Tenant:    info.Tenant,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", StateInfo{}, fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	state := encoded + "." + s.sign(encoded)
+
+	if s.nonces != nil {
+		if err := s.nonces.Save(nonceKeyPrefix+payload.Nonce, StateInfo{CreatedAt: info.CreatedAt, ExpiresAt: info.ExpiresAt, Tenant: info.Tenant}); err != nil {
+			return "", StateInfo{}, fmt.Errorf("failed to store state nonce: %w", err)
+		}
+	}
+
+	if info.CodeVerifier != "" {
+		info.CodeVerifier = s.deriveVerifier(payload.Nonce)
+	}
+	return state, info, nil
+}
+
+// decode verifies the signature and expiry of a state without consuming it
+func (s *stateSigner) decode(state string, withVerifier bool) (StateInfo, string, error) {
+	encoded, signature, found := strings.Cut(state, ".")
+	if !found {
+		return StateInfo{}, "", fmt.Errorf("malformed state parameter")
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return StateInfo{}, "", fmt.Errorf("invalid state signature")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return StateInfo{}, "", fmt.Errorf("malformed state parameter: %w", err)
+	}
+	var payload signedStatePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return StateInfo{}, "", fmt.Errorf("malformed state parameter: %w", err)
+	}
+
+	info := StateInfo{
+		CreatedAt: time.Unix(payload.IssuedAt, 0),
+		ExpiresAt: time.Unix(payload.ExpiresAt, 0),
+		ReturnTo:  payload.ReturnTo,
+		Tenant:    payload.Tenant,
+	}
+	if time.Now().After(info.ExpiresAt) {
+		return StateInfo{}, "", fmt.Errorf("state parameter has expired")
+	}
+	if withVerifier {
+		info.CodeVerifier = s.deriveVerifier(payload.Nonce)
+	}
+	return info, payload.Nonce, nil
+}
+
+// consume verifies a state and records its nonce so it cannot be used again
+func (s *stateSigner) consume(state string, withVerifier bool) (StateInfo, error) {
+	info, nonce, err := s.decode(state, withVerifier)
+	if err != nil {
+		return StateInfo{}, err
+	}
+
+	// A shared store holds one entry per issued nonce; taking it is the replay check
+	if s.nonces != nil {
+		_, exists, err := s.nonces.Take(nonceKeyPrefix + nonce)
+		if err != nil {
+			return StateInfo{}, fmt.Errorf("failed to load state nonce: %w", err)
+		}
+		if !exists {
+			return StateInfo{}, fmt.Errorf("state parameter has already been used")
+		}
+		return info, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for n, expiresAt := range s.usedNonces {
+		if now.After(expiresAt) {
+			delete(s.usedNonces, n)
+		}
+	}
+
+	if _, used := s.usedNonces[nonce]; used {
+		return StateInfo{}, fmt.Errorf("state parameter has already been used")
+	}
+	s.usedNonces[nonce] = info.ExpiresAt
+
+	return info, nil
+}
+
+// sign returns the base64url HMAC-SHA256 signature of value
+func (s *stateSigner) sign(value string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("state:" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// deriveVerifier derives the PKCE code verifier for a nonce
+func (s *stateSigner) deriveVerifier(nonce string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("pkce:" + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,179 @@
+package zoomalert
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStateInfo(ttl time.Duration) StateInfo {
+	return StateInfo{
+		CreatedAt:    time.Now(),
+		ExpiresAt:    time.Now().Add(ttl),
+		CodeVerifier: "pending",
+		ReturnTo:     "/done",
+		Tenant:       "acme",
+	}
+}
+
+func TestStateSignerDecode(t *testing.T) {
+	signer := newStateSigner("state-secret")
+	state, _, err := signer.issue(newTestStateInfo(oauthStateTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := signer.issue(newTestStateInfo(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, signature, _ := strings.Cut(state, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), "/done", "/evil", 1)))
+
+	tests := []struct {
+		name    string
+		state   string
+		wantErr string
+	}{
+		{"valid", state, ""},
+		{"tampered payload", forged + "." + signature, "invalid state signature"},
+		{"tampered MAC", encoded + "." + strings.Repeat("A", len(signature)), "invalid state signature"},
+		{"missing MAC", encoded, "malformed state parameter"},
+		{"other secret", mustIssue(t, newStateSigner("other-secret")), "invalid state signature"},
+		{"expired", expired, "state parameter has expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, _, err := signer.decode(tt.state, false)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("decode failed: %v", err)
+				}
+				if info.ReturnTo != "/done" || info.Tenant != "acme" {
+					t.Fatalf("decoded %+v, want the issued return_to and tenant", info)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("decode error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustIssue(t *testing.T, signer *stateSigner) string {
+	t.Helper()
+	state, _, err := signer.issue(newTestStateInfo(oauthStateTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestStateSignerConsumeOnce(t *testing.T) {
+	t.Run("process memory", func(t *testing.T) {
+		signer := newStateSigner("state-secret")
+		state := mustIssue(t, signer)
+
+		if _, err := signer.consume(state, false); err != nil {
+			t.Fatalf("first use rejected: %v", err)
+		}
+		if _, err := signer.consume(state, false); err == nil {
+			t.Fatal("replayed state accepted")
+		}
+	})
+
+	// Replicas sharing a store redeem a state once between them
+	t.Run("shared store", func(t *testing.T) {
+		store := NewMemoryStateStore()
+		issuer, other := newStateSigner("state-secret"), newStateSigner("state-secret")
+		issuer.nonces, other.nonces = store, store
+		state := mustIssue(t, issuer)
+
+		if _, err := other.consume(state, false); err != nil {
+			t.Fatalf("first use on another replica rejected: %v", err)
+		}
+		if _, err := issuer.consume(state, false); err == nil {
+			t.Fatal("state replayed on the issuing replica accepted")
+		}
+		if _, err := other.consume(state, false); err == nil {
+			t.Fatal("state replayed on the same replica accepted")
+		}
+	})
+
+	// A store without the nonce (never issued, or already taken) rejects the state
+	t.Run("nonce unknown to the store", func(t *testing.T) {
+		signer := newStateSigner("state-secret")
+		state := mustIssue(t, signer)
+		signer.nonces = NewMemoryStateStore()
+
+		if _, err := signer.consume(state, false); err == nil {
+			t.Fatal("state without a stored nonce accepted")
+		}
+	})
+}
+
+func TestStateSignerVerifier(t *testing.T) {
+	signer := newStateSigner("state-secret")
+	state, issued, err := signer.issue(newTestStateInfo(oauthStateTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.CodeVerifier == "" || issued.CodeVerifier == "pending" {
+		t.Fatalf("issued verifier = %q, want one derived from the nonce", issued.CodeVerifier)
+	}
+	if strings.Contains(state, issued.CodeVerifier) {
+		t.Fatal("verifier travels inside the state")
+	}
+
+	looked, _, err := signer.decode(state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumed, err := signer.consume(state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range []StateInfo{looked, consumed} {
+		if pkceChallenge(info.CodeVerifier) != pkceChallenge(issued.CodeVerifier) {
+			t.Fatalf("verifier %q does not match the issued challenge", info.CodeVerifier)
+		}
+	}
+
+	// Without PKCE no verifier is issued or recovered
+	info := newTestStateInfo(oauthStateTTL)
+	info.CodeVerifier = ""
+	state, issued, err = signer.issue(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := signer.decode(state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.CodeVerifier != "" || decoded.CodeVerifier != "" {
+		t.Fatalf("verifiers = %q, %q, want none", issued.CodeVerifier, decoded.CodeVerifier)
+	}
+}
+
+func TestMemoryStateStoreTake(t *testing.T) {
+	store := NewMemoryStateStore()
+	if err := store.Save("live", newTestStateInfo(oauthStateTTL)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("expired", newTestStateInfo(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := store.Take("live"); !ok {
+		t.Fatal("live state not found")
+	}
+	if _, ok, _ := store.Take("live"); ok {
+		t.Fatal("state taken twice")
+	}
+	if _, ok, _ := store.Take("expired"); ok {
+		t.Fatal("expired state taken")
+	}
+}
@@ -0,0 +1,752 @@
+package zoomalert
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSyslogMessageBytes bounds one syslog message, over UDP and TCP alike
+const maxSyslogMessageBytes = 64 * 1024
+
+// maxSyslogConnections bounds concurrent TCP connections; further connections are
+// closed on accept
+const maxSyslogConnections = 256
+
+// syslogIdleTimeout closes TCP connections that send nothing for this long
+const syslogIdleTimeout = 5 * time.Minute
+
+// maxSyslogTitleRunes bounds the part of a message shown as the alert title
+const maxSyslogTitleRunes = 100
+
+// defaultSyslogRuleRateLimit applies to rules without a rate_limit, so a chatty
+// device can't flood a channel
+var defaultSyslogRuleRateLimit = RateLimit{Rate: 10.0 / 60, Burst: 10}
+
+// syslogFacilities are the facility names, indexed by facility code
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogSeverities are the severity names, indexed by severity code
+var syslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// syslogSeverityAliases are other common spellings of severity names
+var syslogSeverityAliases = map[string]int{
+	"emergency": 0, "panic": 0, "critical": 2, "error": 3, "warn": 4, "informational": 6,
+}
+
+// SyslogConfig configures the syslog listener. At least one of UDP and TCP must be set.
+type SyslogConfig struct {
+	// UDP and TCP are listen addresses, e.g. ":5514"
+	UDP string `json:"udp,omitempty"`
+	TCP string `json:"tcp,omitempty"`
+	// Rules are tried in order; the first match handles the message. Messages
+	// matching no rule are ignored.
+	Rules []SyslogRule `json:"rules"`
+}
+
+// SyslogRule matches syslog messages and says where to send them
+type SyslogRule struct {
+	// Name identifies the rule in alerts and logs
+	Name string `json:"name"`
+	// Facilities are facility names or codes; empty matches every facility
+	Facilities []string `json:"facilities,omitempty"`
+	// Severity is a threshold: "err" matches err, crit, alert and emerg
+	Severity string `json:"severity,omitempty"`
+	// Host and App are globs on the hostname and app name (or tag)
+	Host string `json:"host,omitempty"`
+	App  string `json:"app,omitempty"`
+	// Message is a regular expression on the message text
+	Message string `json:"message,omitempty"`
+	// Level overrides the level derived from the syslog severity
+	Level string `json:"level,omitempty"`
+	// RateLimit caps the rule's alerts, e.g. "5/m"; defaults to 10/m. Messages
+	// over the limit are counted and reported with the next alert.
+	RateLimit string `json:"rate_limit,omitempty"`
+	// Drop ignores matching messages, e.g. to exclude noise ahead of broader rules
+	Drop bool `json:"drop,omitempty"`
+	Destination
+}
+
+// syslogMessage is a parsed RFC 5424 or RFC 3164 message
+type syslogMessage struct {
+	Facility  int
+	Severity  int
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	ProcID    string
+	MsgID     string
+	Message   string
+}
+
+// syslogRule is a compiled SyslogRule
+type syslogRule struct {
+	SyslogRule
+	facilities  map[int]bool
+	maxSeverity int
+	message     *regexp.Regexp
+	level       AlertLevel
+	limiter     *rateLimiter
+
+	mutex sync.Mutex
+	// suppressed counts messages dropped by the rate limit since the last alert
+	suppressed int
+}
+
+// validate checks the listen addresses and compiles every rule
+func (c *SyslogConfig) validate() error {
+	if c.UDP == "" && c.TCP == "" {
+		return fmt.Errorf("a udp or tcp listen address is required")
+	}
+	_, err := compileSyslogRules(c.Rules)
+	return err
+}
+
+// compileSyslogRules validates and compiles rules
+func compileSyslogRules(rules []SyslogRule) ([]*syslogRule, error) {
+	compiled := make([]*syslogRule, 0, len(rules))
+	for i, cfg := range rules {
+		name := cfg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		rule, err := compileSyslogRule(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		rule.Name = name
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+// compileSyslogRule validates and compiles one rule
+func compileSyslogRule(cfg SyslogRule) (*syslogRule, error) {
+	rule := &syslogRule{SyslogRule: cfg, maxSeverity: len(syslogSeverities) - 1}
+
+	if len(cfg.Facilities) > 0 {
+		rule.facilities = make(map[int]bool, len(cfg.Facilities))
+		for _, name := range cfg.Facilities {
+			facility, ok := parseSyslogFacility(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown facility %q", name)
+			}
+			rule.facilities[facility] = true
+		}
+	}
+	if cfg.Severity != "" {
+		severity, ok := parseSyslogSeverity(cfg.Severity)
+		if !ok {
+			return nil, fmt.Errorf("unknown severity %q", cfg.Severity)
+		}
+		rule.maxSeverity = severity
+	}
+	for _, pattern := range []string{cfg.Host, cfg.App} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.Message != "" {
+		re, err := regexp.Compile(cfg.Message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message pattern: %w", err)
+		}
+		rule.message = re
+	}
+	if cfg.Drop {
+		return rule, nil
+	}
+
+	if cfg.Level != "" {
+		level, err := ParseAlertLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		rule.level = level
+	}
+	limit := defaultSyslogRuleRateLimit
+	if cfg.RateLimit != "" {
+		var err error
+		if limit, err = ParseRateLimit(cfg.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	rule.limiter = newRateLimiter(limit)
+	if err := cfg.Destination.validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// matches reports whether the rule applies to a message
+func (rule *syslogRule) matches(msg syslogMessage) bool {
+	if rule.facilities != nil && !rule.facilities[msg.Facility] {
+		return false
+	}
+	if msg.Severity > rule.maxSeverity {
+		return false
+	}
+	if rule.Host != "" {
+		if ok, _ := path.Match(rule.Host, msg.Hostname); !ok {
+			return false
+		}
+	}
+	if rule.App != "" {
+		if ok, _ := path.Match(rule.App, msg.AppName); !ok {
+			return false
+		}
+	}
+	return rule.message == nil || rule.message.MatchString(msg.Message)
+}
+
+// allow applies the rule's rate limit. It returns how many messages were
+// suppressed since the last allowed one.
+func (rule *syslogRule) allow(now time.Time) (bool, int) {
+	rule.mutex.Lock()
+	defer rule.mutex.Unlock()
+
+	if ok, _ := rule.limiter.allow(rule.Name, now); !ok {
+		rule.suppressed++
+		return false, 0
+	}
+	suppressed := rule.suppressed
+	rule.suppressed = 0
+	return true, suppressed
+}
+
+// alert renders a matched message
+func (rule *syslogRule) alert(msg syslogMessage, suppressed int) Alert {
+	level := rule.level
+	if level == "" {
+		level = syslogSeverityLevel(msg.Severity)
+	}
+
+	text := strings.TrimSpace(msg.Message)
+	title, _, _ := strings.Cut(text, "\n")
+	if utf8.RuneCountInString(title) > maxSyslogTitleRunes {
+		title = string([]rune(title)[:maxSyslogTitleRunes-1]) + "…"
+	}
+	if title == "" {
+		title = "Syslog " + syslogSeverities[msg.Severity]
+	}
+	if msg.Hostname != "" {
+		title = msg.Hostname + ": " + title
+	}
+	if title == msg.Hostname+": "+text {
+		// The title already shows the whole message
+		text = ""
+	}
+
+	fields := []Field{
+		{Key: "Host", Value: msg.Hostname},
+		{Key: "Facility", Value: syslogFacilityName(msg.Facility)},
+		{Key: "Severity", Value: syslogSeverities[msg.Severity]},
+	}
+	if msg.AppName != "" {
+		app := msg.AppName
+		if msg.ProcID != "" {
+			app += "[" + msg.ProcID + "]"
+		}
+		fields = append(fields, Field{Key: "App", Value: app})
+	}
+	if !msg.Timestamp.IsZero() {
+		fields = append(fields, Field{Key: "Time", Value: msg.Timestamp.Format(time.RFC3339)})
+	}
+	if suppressed > 0 {
+		fields = append(fields, Field{Key: "Suppressed", Value: fmt.Sprintf("%d earlier matches (rate limit)", suppressed)})
+	}
+
+	return Alert{
+		Title:  title,
+		Level:  level,
+		Text:   text,
+		Fields: fields,
+		Footer: "Syslog rule " + rule.Name,
+	}
+}
+
+// syslogSeverityLevel maps syslog severities to alert levels
+func syslogSeverityLevel(severity int) AlertLevel {
+	switch {
+	case severity <= 2:
+		return AlertLevelCritical
+	case severity == 3:
+		return AlertLevelError
+	case severity == 4:
+		return AlertLevelWarning
+	}
+	return AlertLevelInfo
+}
+
+// parseSyslogFacility parses a facility name or code
+func parseSyslogFacility(s string) (int, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, err := strconv.Atoi(s); err == nil {
+		return code, code >= 0 && code < len(syslogFacilities)
+	}
+	for code, name := range syslogFacilities {
+		if name == s {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// syslogFacilityName names a facility code
+func syslogFacilityName(facility int) string {
+	if facility >= 0 && facility < len(syslogFacilities) {
+		return syslogFacilities[facility]
+	}
+	return strconv.Itoa(facility)
+}
+
+// parseSyslogSeverity parses a severity name or code
+func parseSyslogSeverity(s string) (int, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, err := strconv.Atoi(s); err == nil {
+		return code, code >= 0 && code < len(syslogSeverities)
+	}
+	for code, name := range syslogSeverities {
+		if name == s {
+			return code, true
+		}
+	}
+	code, ok := syslogSeverityAliases[s]
+	return code, ok
+}
+
+// rfc3164TagPattern matches the TAG[pid]: prefix of RFC 3164 message content
+var rfc3164TagPattern = regexp.MustCompile(`^([^\s:\[\]]+)(?:\[([^\]]*)\])?:\s?`)
+
+// parseSyslog parses an RFC 5424 message, falling back to RFC 3164 and then to
+// treating the whole line as the message. now dates RFC 3164 timestamps, which
+// have no year.
+func parseSyslog(data []byte, now time.Time) syslogMessage {
+	// RFC 3164 section 4.3.3: messages without a PRI are user.notice
+	msg := syslogMessage{Facility: 1, Severity: 5}
+	rest := strings.TrimRight(string(data), "\r\n\x00")
+
+	if strings.HasPrefix(rest, "<") {
+		if end := strings.IndexByte(rest, '>'); end > 1 && end <= 4 {
+			if pri, err := strconv.Atoi(rest[1:end]); err == nil && pri >= 0 && pri <= 191 {
+				msg.Facility, msg.Severity = pri/8, pri%8
+				rest = rest[end+1:]
+			}
+		}
+	}
+
+	if after, ok := strings.CutPrefix(rest, "1 "); ok {
+		if parseRFC5424(after, &msg) {
+			return msg
+		}
+	}
+	parseRFC3164(rest, now, &msg)
+	return msg
+}
+
+// parseRFC5424 parses what follows "<PRI>1 ". msg is left untouched when s
+// isn't a valid RFC 5424 message.
+func parseRFC5424(s string, out *syslogMessage) bool {
+	msg := *out
+	header := strings.SplitN(s, " ", 6)
+	if len(header) < 5 {
+		return false
+	}
+	nilValue := func(v string) string {
+		if v == "-" {
+			return ""
+		}
+		return v
+	}
+	if header[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return false
+		}
+		msg.Timestamp = ts
+	}
+	msg.Hostname = nilValue(header[1])
+	msg.AppName = nilValue(header[2])
+	msg.ProcID = nilValue(header[3])
+	msg.MsgID = nilValue(header[4])
+	if len(header) == 5 {
+		*out = msg
+		return true
+	}
+
+	rest := header[5]
+	if after, ok := strings.CutPrefix(rest, "-"); ok {
+		rest = after
+	} else {
+		// Skip structured data elements: [id param="value" ...], where values
+		// may contain escaped quotes and brackets
+		for strings.HasPrefix(rest, "[") {
+			end := structuredDataEnd(rest)
+			if end < 0 {
+				return false
+			}
+			rest = rest[end+1:]
+		}
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	msg.Message = strings.TrimPrefix(rest, "\ufeff")
+	*out = msg
+	return true
+}
+
+// structuredDataEnd returns the index of the "]" closing the element s starts with
+func structuredDataEnd(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseRFC3164 parses "Mmm dd hh:mm:ss HOSTNAME TAG[pid]: MSG", tolerating the
+// missing hostnames, RFC 3339 timestamps and bare messages sent in practice
+func parseRFC3164(s string, now time.Time, msg *syslogMessage) {
+	rest := s
+	if len(rest) >= len(time.Stamp) {
+		if ts, err := time.ParseInLocation(time.Stamp, rest[:len(time.Stamp)], now.Location()); err == nil {
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// A December message received in January is from last year
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = ts
+			rest = strings.TrimPrefix(rest[len(time.Stamp):], " ")
+		}
+	}
+	if msg.Timestamp.IsZero() {
+		if first, after, ok := strings.Cut(rest, " "); ok {
+			if ts, err := time.Parse(time.RFC3339Nano, first); err == nil {
+				msg.Timestamp = ts
+				rest = after
+			}
+		}
+	}
+
+	if !msg.Timestamp.IsZero() {
+		// Without a timestamp there is no telling a hostname from the message
+		if host, after, ok := strings.Cut(rest, " "); ok && !rfc3164TagPattern.MatchString(host+" ") {
+			msg.Hostname = host
+			rest = after
+		}
+	}
+	if m := rfc3164TagPattern.FindStringSubmatch(rest); m != nil {
+		msg.AppName, msg.ProcID = m[1], m[2]
+		rest = rest[len(m[0]):]
+	}
+	msg.Message = rest
+}
+
+// splitSyslogFrames splits a TCP stream into messages framed by octet counting
+// ("123 <34>1 ...") or by newlines (RFC 6587)
+func splitSyslogFrames(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	if data[0] >= '1' && data[0] <= '9' {
+		if sp := bytes.IndexByte(data, ' '); sp > 0 && sp <= 6 {
+			if n, err := strconv.Atoi(string(data[:sp])); err == nil {
+				if n > maxSyslogMessageBytes {
+					return 0, nil, fmt.Errorf("syslog frame of %d bytes is too long", n)
+				}
+				if len(data) >= sp+1+n {
+					return sp + 1 + n, data[sp+1 : sp+1+n], nil
+				}
+				if atEOF {
+					return 0, nil, io.ErrUnexpectedEOF
+				}
+				return 0, nil, nil
+			}
+		} else if sp < 0 && len(data) <= 6 && !atEOF {
+			// Wait for the rest of the length
+			return 0, nil, nil
+		}
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// syslogReceiver listens for syslog messages and sends alerts for those matching
+// its rules through the asynchronous job queue
+type syslogReceiver struct {
+	config     *SyslogConfig
+	rules      []*syslogRule
+	dispatcher *dispatcher
+	logger     *slog.Logger
+	// maxConns and idleTimeout default to maxSyslogConnections and syslogIdleTimeout
+	maxConns    int
+	idleTimeout time.Duration
+
+	mutex      sync.Mutex
+	started    bool
+	packetConn net.PacketConn
+	listener   net.Listener
+	conns      map[net.Conn]struct{}
+	readers    sync.WaitGroup
+}
+
+// newSyslogReceiver compiles the rules of a syslog configuration
+func newSyslogReceiver(config *SyslogConfig, d *dispatcher, logger *slog.Logger) (*syslogReceiver, error) {
+	rules, err := compileSyslogRules(config.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	return &syslogReceiver{
+		config:      config,
+		rules:       rules,
+		dispatcher:  d,
+		logger:      logger,
+		maxConns:    maxSyslogConnections,
+		idleTimeout: syslogIdleTimeout,
+		conns:       make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// start opens the configured listeners; it does nothing if they are open
+func (s *syslogReceiver) start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started {
+		return nil
+	}
+
+	if s.config.UDP != "" {
+		conn, err := net.ListenPacket("udp", s.config.UDP)
+		if err != nil {
+			return fmt.Errorf("failed to listen for syslog on udp %s: %w", s.config.UDP, err)
+		}
+		s.packetConn = conn
+	}
+	if s.config.TCP != "" {
+		listener, err := net.Listen("tcp", s.config.TCP)
+		if err != nil {
+			if s.packetConn != nil {
+				s.packetConn.Close()
+				s.packetConn = nil
+			}
+			return fmt.Errorf("failed to listen for syslog on tcp %s: %w", s.config.TCP, err)
+		}
+		s.listener = listener
+	}
+	s.started = true
+
+	if s.packetConn != nil {
+		s.logger.Info("Starting syslog listener", "network", "udp", "address", s.packetConn.LocalAddr().String())
+		s.readers.Add(1)
+		go s.serveUDP(s.packetConn)
+	}
+	if s.listener != nil {
+		s.logger.Info("Starting syslog listener", "network", "tcp", "address", s.listener.Addr().String())
+		s.readers.Add(1)
+		go s.serveTCP(s.listener)
+	}
+	return nil
+}
+
+// stop closes the listeners and connections and waits for their readers, so no
+// alert is queued after it returns
+func (s *syslogReceiver) stop() {
+	s.mutex.Lock()
+	if !s.started {
+		s.mutex.Unlock()
+		return
+	}
+	s.started = false
+	if s.packetConn != nil {
+		s.packetConn.Close()
+		s.packetConn = nil
+	}
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.logger.Info("Shutting down syslog listener")
+	s.readers.Wait()
+}
+
+// serveUDP reads one message per datagram
+func (s *syslogReceiver) serveUDP(conn net.PacketConn) {
+	defer s.readers.Done()
+	buf := make([]byte, maxSyslogMessageBytes)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Syslog UDP listener failed", "error", err)
+			}
+			return
+		}
+		s.handle(buf[:n], addr)
+	}
+}
+
+// serveTCP accepts connections until the listener is closed
+func (s *syslogReceiver) serveTCP(listener net.Listener) {
+	defer s.readers.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Syslog TCP listener failed", "error", err)
+			}
+			return
+		}
+
+		s.mutex.Lock()
+		if !s.started {
+			s.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		if len(s.conns) >= s.maxConns {
+			s.mutex.Unlock()
+			s.logger.Warn("Rejecting syslog connection: too many connections", "remote", conn.RemoteAddr().String())
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.readers.Add(1)
+		s.mutex.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn reads framed messages from one TCP connection
+func (s *syslogReceiver) serveConn(conn net.Conn) {
+	defer s.readers.Done()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(idleConn{Conn: conn, timeout: s.idleTimeout})
+	scanner.Buffer(make([]byte, 4096), maxSyslogMessageBytes+8)
+	scanner.Split(splitSyslogFrames)
+	for scanner.Scan() {
+		s.handle(scanner.Bytes(), conn.RemoteAddr())
+	}
+	switch err := scanner.Err(); {
+	case err == nil, errors.Is(err, net.ErrClosed):
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		s.logger.Debug("Closing idle syslog connection", "remote", conn.RemoteAddr().String())
+	default:
+		s.logger.Warn("Dropping syslog connection", "remote", conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+// idleConn extends the read deadline before every read, so a connection that
+// stays silent for timeout fails its next read
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+// Read reads from the connection with a fresh idle deadline
+func (c idleConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(p)
+}
+
+// handle matches a message against the rules and queues an alert for the first
+// matching rule
+func (s *syslogReceiver) handle(data []byte, from net.Addr) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+	now := time.Now()
+	msg := parseSyslog(data, now)
+	if msg.Hostname == "" && from != nil {
+		if host, _, err := net.SplitHostPort(from.String()); err == nil {
+			msg.Hostname = host
+		}
+	}
+
+	for _, rule := range s.rules {
+		if !rule.matches(msg) {
+			continue
+		}
+		if rule.Drop {
+			return
+		}
+		allowed, suppressed := rule.allow(now)
+		if !allowed {
+			s.logger.Debug("Syslog rule rate limited", "rule", rule.Name, "host", msg.Hostname)
+			return
+		}
+		s.send(rule, rule.alert(msg, suppressed))
+		return
+	}
+}
+
+// send queues an alert for a rule's destination
+func (s *syslogReceiver) send(rule *syslogRule, alert Alert) {
+	o := sendOptions{
+		tenant: rule.Tenant,
+		bot:    rule.Bot,
+		source: "syslog:" + rule.Name,
+	}
+	dl, err := s.dispatcher.prepare(rule.recipients(), message{content: alert.Content(), level: alert.Level}, o)
+	if err != nil {
+		s.logger.Warn("Syslog alert rejected", "rule", rule.Name, "error", err)
+		return
+	}
+	if _, err := s.dispatcher.jobs.submit(dl, ""); err != nil {
+		s.dispatcher.abandon(dl)
+		s.logger.Warn("Failed to queue syslog alert", "rule", rule.Name, "error", err)
+	}
+}
+
+// StartSyslog opens the syslog listeners configured in Integrations.Syslog.
+// StartHTTPServer calls it; applications serving Handler themselves call it once
+// at startup. Shutdown closes the listeners. It does nothing when syslog is not
+// configured.
+func (m *ZoomAlertModule) StartSyslog() error {
+	if m.syslog == nil {
+		return nil
+	}
+	return m.syslog.start()
+}
@@ -0,0 +1,249 @@
+package zoomalert
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input string
+		now   time.Time
+		want  syslogMessage
+	}{
+		{
+			name:  "RFC 5424 with structured data and BOM",
+			input: "<165>1 2026-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Application\"] \ufeffAn application event\n",
+			want: syslogMessage{
+				Facility: 20, Severity: 5,
+				Timestamp: time.Date(2026, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				Hostname:  "mymachine.example.com", AppName: "evntslog", MsgID: "ID47",
+				Message: "An application event",
+			},
+		},
+		{
+			name:  "RFC 5424 structured data with escaped brackets and quotes",
+			input: `<11>1 - db-1 postgres 4242 - [a x="y\]z"][b c="d\"]"] disk full`,
+			want: syslogMessage{
+				Facility: 1, Severity: 3,
+				Hostname: "db-1", AppName: "postgres", ProcID: "4242",
+				Message: "disk full",
+			},
+		},
+		{
+			name:  "RFC 5424 without structured data",
+			input: "<14>1 - - - - - -\ufeffhello",
+			want:  syslogMessage{Facility: 1, Severity: 6, Message: "hello"},
+		},
+		{
+			name:  "RFC 5424 header only",
+			input: "<14>1 - web-1 nginx - -",
+			want:  syslogMessage{Facility: 1, Severity: 6, Hostname: "web-1", AppName: "nginx"},
+		},
+		{
+			name:  "RFC 5424 missing header fields",
+			input: "<34>1 2026-10-11T22:14:15Z web-1",
+			want:  syslogMessage{Facility: 4, Severity: 2, Message: "1 2026-10-11T22:14:15Z web-1"},
+		},
+		{
+			name:  "RFC 5424 unterminated structured data",
+			input: `<34>1 - web-1 nginx - - [a x="y"`,
+			want:  syslogMessage{Facility: 4, Severity: 2, Message: `1 - web-1 nginx - - [a x="y"`},
+		},
+		{
+			name:  "RFC 5424 invalid timestamp",
+			input: "<34>1 yesterday web-1 nginx - - - hi",
+			want:  syslogMessage{Facility: 4, Severity: 2, Message: "1 yesterday web-1 nginx - - - hi"},
+		},
+		{
+			name:  "non-numeric PRI",
+			input: "<abc>hello",
+			want:  syslogMessage{Facility: 1, Severity: 5, Message: "<abc>hello"},
+		},
+		{
+			name:  "PRI out of range",
+			input: "<192>hello",
+			want:  syslogMessage{Facility: 1, Severity: 5, Message: "<192>hello"},
+		},
+		{
+			name:  "empty PRI",
+			input: "<>hello",
+			want:  syslogMessage{Facility: 1, Severity: 5, Message: "<>hello"},
+		},
+		{
+			name:  "unterminated PRI",
+			input: "<34 hello",
+			want:  syslogMessage{Facility: 1, Severity: 5, Message: "<34 hello"},
+		},
+		{
+			name:  "RFC 3164",
+			input: "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8\r\n",
+			want: syslogMessage{
+				Facility: 4, Severity: 2,
+				Timestamp: time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "mymachine", AppName: "su", ProcID: "123",
+				Message: "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name:  "RFC 3164 from last year",
+			input: "<13>Dec 31 23:59:59 gw-1 kernel: link down",
+			now:   time.Date(2027, 1, 1, 0, 0, 5, 0, time.UTC),
+			want: syslogMessage{
+				Facility: 1, Severity: 5,
+				Timestamp: time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC),
+				Hostname:  "gw-1", AppName: "kernel",
+				Message: "link down",
+			},
+		},
+		{
+			name:  "RFC 3164 without hostname",
+			input: "<78>Oct 11 22:14:15 cron[9]: job done",
+			want: syslogMessage{
+				Facility: 9, Severity: 6,
+				Timestamp: time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC),
+				AppName:   "cron", ProcID: "9",
+				Message: "job done",
+			},
+		},
+		{
+			name:  "RFC 3164 with RFC 3339 timestamp",
+			input: "<30>2026-10-11T22:14:15Z nas-1 backup: finished",
+			want: syslogMessage{
+				Facility: 3, Severity: 6,
+				Timestamp: time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "nas-1", AppName: "backup",
+				Message: "finished",
+			},
+		},
+		{
+			name:  "bare message",
+			input: "disk full\x00",
+			want:  syslogMessage{Facility: 1, Severity: 5, Message: "disk full"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.now
+			if at.IsZero() {
+				at = now
+			}
+			got := parseSyslog([]byte(tt.input), at)
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("timestamp = %v, want %v", got.Timestamp, tt.want.Timestamp)
+			}
+			got.Timestamp, tt.want.Timestamp = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("parseSyslog(%q)\n got %+v\nwant %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestSyslogReceiver(t *testing.T) *syslogReceiver {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s, err := newSyslogReceiver(&SyslogConfig{TCP: "127.0.0.1:0"}, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// dialSyslog connects to the receiver and waits until it serves the connection
+func dialSyslog(t *testing.T, s *syslogReceiver, wantConns int) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mutex.Lock()
+		n := len(s.conns)
+		s.mutex.Unlock()
+		if n >= wantConns || time.Now().After(deadline) {
+			return conn
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitClosed reports whether the server closes conn within timeout
+func waitClosed(conn net.Conn, timeout time.Duration) bool {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	_, err := conn.Read(make([]byte, 1))
+	return err == io.EOF
+}
+
+func TestSyslogConnectionLimit(t *testing.T) {
+	s := newTestSyslogReceiver(t)
+	s.maxConns = 1
+	if err := s.start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.stop()
+
+	first := dialSyslog(t, s, 1)
+	rejected := dialSyslog(t, s, 1)
+	if !waitClosed(rejected, 5*time.Second) {
+		t.Fatal("connection over the limit was not closed")
+	}
+	if waitClosed(first, 100*time.Millisecond) {
+		t.Fatal("connection within the limit was closed")
+	}
+
+	// The slot frees up once the first connection goes away
+	first.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mutex.Lock()
+		n := len(s.conns)
+		s.mutex.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("closed connection still counted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	next := dialSyslog(t, s, 1)
+	if waitClosed(next, 100*time.Millisecond) {
+		t.Fatal("connection after a freed slot was closed")
+	}
+}
+
+func TestSyslogIdleTimeout(t *testing.T) {
+	s := newTestSyslogReceiver(t)
+	s.idleTimeout = 200 * time.Millisecond
+	if err := s.start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.stop()
+
+	conn := dialSyslog(t, s, 1)
+
+	// Traffic extends the deadline
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := conn.Write([]byte("<14>still here\n")); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+	if waitClosed(conn, 100*time.Millisecond) {
+		t.Fatal("active connection was closed")
+	}
+
+	if !waitClosed(conn, 5*time.Second) {
+		t.Fatal("idle connection was not closed")
+	}
+}
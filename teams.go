@@ -0,0 +1,398 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// adaptiveCardContentType marks Adaptive Card attachments of Teams messages
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// TeamsConfig binds the tokens of Teams-compatible incoming webhook URLs
+// (/teams/{token}) to destinations
+type TeamsConfig struct {
+	Hooks HookTokens `json:"hooks"`
+}
+
+// teamsAction is a MessageCard potentialAction or an Adaptive Card action
+type teamsAction struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	// OpenUri targets
+	Targets []struct {
+		OS  string `json:"os"`
+		URI string `json:"uri"`
+	} `json:"targets"`
+	// ViewAction targets
+	Target []string `json:"target"`
+
+	// Adaptive Card Action.OpenUrl
+	AdaptiveType string `json:"type"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	Style        string `json:"style"`
+}
+
+// teamsFact is a MessageCard fact or an Adaptive Card FactSet fact
+type teamsFact struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsSection is a MessageCard section
+type teamsSection struct {
+	Title            string      `json:"title"`
+	ActivityTitle    string      `json:"activityTitle"`
+	ActivitySubtitle string      `json:"activitySubtitle"`
+	ActivityText     string      `json:"activityText"`
+	Text             string      `json:"text"`
+	Facts            []teamsFact `json:"facts"`
+	Images           []struct {
+		Image string `json:"image"`
+		Title string `json:"title"`
+	} `json:"images"`
+	PotentialAction []teamsAction `json:"potentialAction"`
+}
+
+// adaptiveElement is an Adaptive Card element. Only the properties of the
+// elements that have a chatbot equivalent are decoded.
+type adaptiveElement struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight"`
+	Color  string `json:"color"`
+	Style  string `json:"style"`
+	// Inlines of a RichTextBlock; each is a TextRun object or a string
+	Inlines []json.RawMessage `json:"inlines"`
+	Facts   []teamsFact       `json:"facts"`
+	// Image
+	URL     string            `json:"url"`
+	AltText string            `json:"altText"`
+	Images  []adaptiveElement `json:"images"`
+	// Container and Column
+	Items []adaptiveElement `json:"items"`
+	// ColumnSet
+	Columns []adaptiveElement `json:"columns"`
+	// ActionSet
+	Actions []teamsAction `json:"actions"`
+}
+
+// adaptiveCard is the content of an Adaptive Card
+type adaptiveCard struct {
+	Type    string            `json:"type"`
+	Body    []adaptiveElement `json:"body"`
+	Actions []teamsAction     `json:"actions"`
+}
+
+// TeamsPayload is a Teams incoming webhook message: an Office 365 connector
+// MessageCard, a message with Adaptive Card attachments, or a bare Adaptive Card
+type TeamsPayload struct {
+	Type string `json:"type"`
+
+	// MessageCard
+	Summary         string         `json:"summary"`
+	Title           string         `json:"title"`
+	Text            string         `json:"text"`
+	ThemeColor      string         `json:"themeColor"`
+	Sections        []teamsSection `json:"sections"`
+	PotentialAction []teamsAction  `json:"potentialAction"`
+
+	// Message with attachments
+	Attachments []struct {
+		ContentType string       `json:"contentType"`
+		Content     adaptiveCard `json:"content"`
+	} `json:"attachments"`
+
+	// Bare Adaptive Card
+	Body    []adaptiveElement `json:"body"`
+	Actions []teamsAction     `json:"actions"`
+}
+
+// TeamsWebhook accepts Teams connector payloads on /teams/{token} and sends them,
+// translated to chatbot content, to the token's destination
+func (h *AlertHandler) TeamsWebhook(w http.ResponseWriter, r *http.Request) {
+	cfg := h.integrations.Teams
+	if cfg == nil {
+		integrationNotConfigured(w, "Teams")
+		return
+	}
+	dest, ok := cfg.Hooks.destination(r.PathValue("token"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, jsonObject{"error": "Webhook not found"})
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	var payload TeamsPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid Teams payload: " + err.Error()})
+		return
+	}
+
+	msg, ok := renderTeams(payload)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Teams payload has no text, title or card"})
+		return
+	}
+
+	o := integrationSendOptions(r, dest)
+	o.source = "teams"
+	h.dispatch(w, r, dest.recipients(), msg, o)
+}
+
+// renderTeams translates a Teams payload. It returns false when the payload has
+// nothing to show.
+func renderTeams(p TeamsPayload) (message, bool) {
+	content := ZoomContent{Body: []any{}}
+	level := AlertLevelInfo
+
+	switch {
+	case len(p.Attachments) > 0:
+		for _, att := range p.Attachments {
+			if att.ContentType != adaptiveCardContentType {
+				continue
+			}
+			level = maxLevel(level, renderAdaptiveCard(att.Content, &content))
+		}
+	case p.Type == "AdaptiveCard":
+		level = renderAdaptiveCard(adaptiveCard{Body: p.Body, Actions: p.Actions}, &content)
+	default:
+		level = renderMessageCard(p, &content)
+	}
+
+	if content.Head.Text == "" {
+		content.Head.Text = p.Summary
+	}
+	if content.Head.Text == "" && len(content.Body) == 0 {
+		return message{}, false
+	}
+	if content.Head.Text == "" {
+		content.Head.Text = "Notification"
+	}
+
+	content.Head.Style = ZoomStyle{Color: level.Color(), Bold: true}
+	if color := teamsColor(p.ThemeColor); color != "" {
+		content.Head.Style.Color = color
+	}
+	return message{content: content, level: level}, true
+}
+
+// renderMessageCard translates a connector MessageCard and returns the level its
+// theme color suggests
+func renderMessageCard(p TeamsPayload, content *ZoomContent) AlertLevel {
+	content.Head.Text = p.Title
+	if p.Text != "" {
+		content.Body = append(content.Body, Message{Type: "message", Text: p.Text, Markdown: true})
+	}
+
+	for _, section := range p.Sections {
+		title := section.Title
+		if title == "" {
+			title = section.ActivityTitle
+		}
+		if title != "" {
+			if content.Head.Text == "" {
+				content.Head.Text = title
+			} else {
+				content.Body = append(content.Body, Message{Type: "message", Text: "**" + title + "**", Markdown: true})
+			}
+		}
+		if section.ActivitySubtitle != "" {
+			content.Body = append(content.Body, Message{Type: "message", Text: "_" + section.ActivitySubtitle + "_", Markdown: true})
+		}
+		for _, text := range []string{section.ActivityText, section.Text} {
+			if text != "" {
+				content.Body = append(content.Body, Message{Type: "message", Text: text, Markdown: true})
+			}
+		}
+		if fields := teamsFields(section.Facts); len(fields) > 0 {
+			content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: fields})
+		}
+		for _, image := range section.Images {
+			if image.Image != "" {
+				content.Body = append(content.Body, imageBlock(image.Image, "", image.Title))
+			}
+		}
+		if actions := teamsActions(section.PotentialAction); len(actions) > 0 {
+			content.Body = append(content.Body, ActionsBlock{Type: "actions", Items: actions})
+		}
+	}
+
+	if actions := teamsActions(p.PotentialAction); len(actions) > 0 {
+		content.Body = append(content.Body, ActionsBlock{Type: "actions", Items: actions})
+	}
+	return levelFromThemeColor(p.ThemeColor)
+}
+
+// renderAdaptiveCard translates an Adaptive Card and returns the level its text and
+// container colors suggest. The first TextBlock becomes the head.
+func renderAdaptiveCard(card adaptiveCard, content *ZoomContent) AlertLevel {
+	level := AlertLevelInfo
+	var walk func(elements []adaptiveElement)
+	walk = func(elements []adaptiveElement) {
+		for _, el := range elements {
+			level = maxLevel(level, maxLevel(adaptiveColorLevel(el.Color), adaptiveColorLevel(el.Style)))
+
+			switch el.Type {
+			case "TextBlock", "RichTextBlock":
+				text := el.Text
+				if el.Type == "RichTextBlock" {
+					text = adaptiveInlines(el.Inlines)
+				}
+				if text == "" {
+					continue
+				}
+				if content.Head.Text == "" {
+					content.Head.Text = text
+				} else if el.Weight == "bolder" || el.Style == "heading" {
+					content.Body = append(content.Body, Message{Type: "message", Text: "**" + text + "**", Markdown: true})
+				} else {
+					content.Body = append(content.Body, Message{Type: "message", Text: text, Markdown: true})
+				}
+
+			case "FactSet":
+				if fields := teamsFields(el.Facts); len(fields) > 0 {
+					content.Body = append(content.Body, FieldsBlock{Type: "fields", Items: fields})
+				}
+
+			case "Image":
+				if el.URL != "" {
+					content.Body = append(content.Body, imageBlock(el.URL, "", el.AltText))
+				}
+
+			case "ImageSet":
+				walk(el.Images)
+
+			case "Container", "Column":
+				walk(el.Items)
+
+			case "ColumnSet":
+				walk(el.Columns)
+
+			case "ActionSet":
+				if actions := teamsActions(el.Actions); len(actions) > 0 {
+					content.Body = append(content.Body, ActionsBlock{Type: "actions", Items: actions})
+				}
+			}
+			// Inputs and media have no chatbot equivalent
+		}
+	}
+	walk(card.Body)
+
+	if actions := teamsActions(card.Actions); len(actions) > 0 {
+		content.Body = append(content.Body, ActionsBlock{Type: "actions", Items: actions})
+	}
+	return level
+}
+
+// adaptiveInlines joins the text of a RichTextBlock's inlines
+func adaptiveInlines(inlines []json.RawMessage) string {
+	var b strings.Builder
+	for _, raw := range inlines {
+		var text string
+		if json.Unmarshal(raw, &text) != nil {
+			var run struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(raw, &run)
+			text = run.Text
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// teamsFields converts facts to fields
+func teamsFields(facts []teamsFact) []Field {
+	fields := make([]Field, 0, len(facts))
+	for _, fact := range facts {
+		key := fact.Name
+		if key == "" {
+			key = fact.Title
+		}
+		fields = append(fields, Field{Key: strings.TrimSuffix(key, ":"), Value: fact.Value})
+	}
+	return fields
+}
+
+// teamsActions converts the link actions (OpenUri, ViewAction, Action.OpenUrl) to
+// buttons. Actions that post back to Teams are dropped.
+func teamsActions(actions []teamsAction) []Action {
+	var items []Action
+	for _, a := range actions {
+		label, target := a.Name, ""
+		switch {
+		case a.Type == "OpenUri":
+			for _, t := range a.Targets {
+				if target == "" || t.OS == "default" {
+					target = t.URI
+				}
+			}
+		case a.Type == "ViewAction" && len(a.Target) > 0:
+			target = a.Target[0]
+		case a.AdaptiveType == "Action.OpenUrl":
+			label, target = a.Title, a.URL
+		}
+		if target == "" {
+			continue
+		}
+		action := LinkAction(label, target)
+		switch a.Style {
+		case "positive":
+			action.Style = "Primary"
+		case "destructive":
+			action.Style = "Danger"
+		}
+		items = append(items, action)
+	}
+	return items
+}
+
+// adaptiveColorLevel maps the Adaptive Card colors and container styles that carry
+// meaning to alert levels
+func adaptiveColorLevel(color string) AlertLevel {
+	switch strings.ToLower(color) {
+	case "attention":
+		return AlertLevelError
+	case "warning":
+		return AlertLevelWarning
+	}
+	return AlertLevelInfo
+}
+
+// teamsColor converts a themeColor ("0076D7" or "#0076D7") to a header color
+func teamsColor(themeColor string) string {
+	hex := strings.TrimPrefix(themeColor, "#")
+	if len(hex) != 6 {
+		return ""
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return ""
+	}
+	return "#" + strings.ToUpper(hex)
+}
+
+// levelFromThemeColor guesses the level of a MessageCard from its theme color:
+// reds are errors and oranges and yellows are warnings
+func levelFromThemeColor(themeColor string) AlertLevel {
+	color := teamsColor(themeColor)
+	if color == "" {
+		return AlertLevelInfo
+	}
+	rgb, _ := strconv.ParseUint(color[1:], 16, 32)
+	red, green, blue := rgb>>16, rgb>>8&0xFF, rgb&0xFF
+	switch {
+	case red >= 0xC0 && green < 0x80 && blue < 0x80:
+		return AlertLevelError
+	case red >= 0xC0 && green >= 0x80 && blue < 0x80:
+		return AlertLevelWarning
+	}
+	return AlertLevelInfo
+}
@@ -0,0 +1,217 @@
+package zoomalert
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultTenantID identifies the tenant built from the module's primary Config
+const DefaultTenantID = "default"
+
+// TenantHeader selects a tenant on HTTP requests that don't carry it in the path
+const TenantHeader = "X-Zoom-Tenant"
+
+// tenantIDPattern restricts tenant identifiers to URL- and env-safe names
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Tenant is a single Zoom account with its own credentials, robot JID, token store
+// and chatbot token cache
+type Tenant struct {
+	id           string
+	config       *Config
+	oauthService *OAuthService
+	zoomService  *ZoomService
+}
+
+// newTenant creates the OAuth and Zoom services for a tenant
+func newTenant(id string, config *Config, logger *slog.Logger, stateStore StateStore) *Tenant {
+	logger = logger.With("tenant", id)
+
+	oauthService := NewOAuthService(config, logger, config.TokenFilePath)
+	oauthService.tenantID = id
+	if stateStore != nil {
+		oauthService.SetStateStore(stateStore)
+	}
+
+	return &Tenant{
+		id:           id,
+		config:       config,
+		oauthService: oauthService,
+		zoomService:  NewZoomService(oauthService, config.ZoomRobotJID, config.ZoomAccountID, logger),
+	}
+}
+
+// ID returns the tenant identifier
+func (t *Tenant) ID() string {
+	return t.id
+}
+
+// SendMessage sends a message to a Zoom user of this tenant by email
+func (t *Tenant) SendMessage(email string, message ZoomContent, opts ...SendOption) error {
+	if email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	_, err := t.send(Recipient{Email: email}, message, collectSendOptions(opts))
+	return err
+}
+
+// send delivers a message with already collected options
+func (t *Tenant) send(to Recipient, message ZoomContent, o sendOptions) (*SendResult, error) {
+	// Looking up users by email needs the user token; channels only need the chatbot token
+	if to.Email != "" && !t.zoomService.IsUserAuthorized() {
+		return nil, fmt.Errorf("tenant %s: %w", t.id, ErrUserNotAuthorized)
+	}
+
+	result, err := t.zoomService.Send(o.bot, to, message)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: failed to send message: %w", t.id, err)
+	}
+
+	return result, nil
+}
+
+// IsUserAuthorized checks if the tenant has user authorization
+func (t *Tenant) IsUserAuthorized() bool {
+	return t.zoomService.IsUserAuthorized()
+}
+
+// AuthStatus returns the tenant's authorization details
+func (t *Tenant) AuthStatus() AuthStatus {
+	return t.zoomService.AuthStatus()
+}
+
+// GetAuthorizationURL returns an OAuth authorization URL bound to this tenant
+func (t *Tenant) GetAuthorizationURL() (string, error) {
+	state, err := t.oauthService.GenerateState()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	return t.oauthService.GetAuthorizationURL(state), nil
+}
+
+// HandleOAuthCallback exchanges the callback code for tokens stored with this tenant
+func (t *Tenant) HandleOAuthCallback(code, state string) error {
+	info, err := t.oauthService.ConsumeState(state)
+	if err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+
+	return t.oauthService.ExchangeCodeForToken(code, info.CodeVerifier)
+}
+
+// RevokeAuthorization revokes the tenant's user authorization and clears its tokens
+func (t *Tenant) RevokeAuthorization() error {
+	return t.oauthService.Revoke()
+}
+
+// ZoomService returns the tenant's ZoomService
+func (t *Tenant) ZoomService() *ZoomService {
+	return t.zoomService
+}
+
+// OAuthService returns the tenant's OAuthService
+func (t *Tenant) OAuthService() *OAuthService {
+	return t.oauthService
+}
+
+// tenantRegistry resolves tenants by identifier
+type tenantRegistry struct {
+	tenants   map[string]*Tenant
+	defaultID string
+}
+
+// newTenantRegistry creates a registry whose default tenant is def
+func newTenantRegistry(def *Tenant) *tenantRegistry {
+	return &tenantRegistry{
+		tenants:   map[string]*Tenant{def.id: def},
+		defaultID: def.id,
+	}
+}
+
+// add registers a tenant
+func (r *tenantRegistry) add(t *Tenant) error {
+	if _, exists := r.tenants[t.id]; exists {
+		return fmt.Errorf("duplicate tenant %q", t.id)
+	}
+	r.tenants[t.id] = t
+	return nil
+}
+
+// get returns the tenant with the given id, or the default tenant when id is empty
+func (r *tenantRegistry) get(id string) (*Tenant, error) {
+	if id == "" {
+		id = r.defaultID
+	}
+	t, exists := r.tenants[id]
+	if !exists {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTenant, id)
+	}
+	return t, nil
+}
+
+// forState finds the tenant that issued an OAuth state, falling back to the default tenant
+func (r *tenantRegistry) forState(state string) *Tenant {
+	for _, t := range r.tenants {
+		if info, err := t.oauthService.lookupState(state); err == nil && info.Tenant == t.id {
+			return t
+		}
+	}
+	return r.tenants[r.defaultID]
+}
+
+// ids returns the sorted tenant identifiers
+func (r *tenantRegistry) ids() []string {
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// loadTenantConfigsFromEnv reads additional tenants listed in ZOOM_TENANTS.
+// Each tenant inherits base and overrides it from ZOOM_TENANT_<ID>_* variables.
+func loadTenantConfigsFromEnv(base *Config) map[string]*Config {
+	ids := splitList(os.Getenv("ZOOM_TENANTS"))
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tenants := make(map[string]*Config, len(ids))
+	for _, id := range ids {
+		prefix := "ZOOM_TENANT_" + envName(id) + "_"
+
+		cfg := *base
+		cfg.Tenants = nil
+		cfg.ZoomAccountID = os.Getenv(prefix + "ACCOUNT_ID")
+		cfg.ZoomClientID = os.Getenv(prefix + "CLIENT_ID")
+		cfg.ZoomClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		cfg.ZoomRobotJID = os.Getenv(prefix + "ROBOT_JID")
+		cfg.ZoomWebhookSecretToken = os.Getenv(prefix + "WEBHOOK_SECRET_TOKEN")
+		cfg.TokenFilePath = filepath.Join(filepath.Dir(base.TokenFilePath), "tokens-"+id+".json")
+		cfg.Bots = loadBotConfigsFromEnv(prefix)
+
+		if val := os.Getenv(prefix + "REDIRECT_URI"); val != "" {
+			cfg.ZoomRedirectURI = val
+		}
+		if val := os.Getenv(prefix + "TOKEN_FILE_PATH"); val != "" {
+			cfg.TokenFilePath = val
+		}
+
+		tenants[id] = &cfg
+	}
+
+	return tenants
+}
+
+// envName converts a tenant or bot name to its environment variable form
+func envName(id string) string {
+	return strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
+}
@@ -0,0 +1,465 @@
+package zoomalert
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Defaults of the webhook mapping auth methods
+const (
+	defaultWebhookTokenHeader     = "X-Webhook-Token"
+	defaultWebhookSignatureHeader = "X-Signature"
+)
+
+// webhookNamePattern restricts mapping names to URL path segments
+var webhookNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// WebhookConfig maps the JSON payloads of a tool without a dedicated receiver to
+// alerts. It is served at /webhooks/{name}.
+//
+// Expressions are either paths into the payload ($.alert.name, alert.tags[0],
+// items.#.id) or, when they contain "{{", Go templates executed with the payload
+// as data.
+type WebhookConfig struct {
+	Auth WebhookAuth `json:"auth"`
+
+	// Title is required; Message, Severity and DedupeKey are optional
+	Title   string `json:"title"`
+	Message string `json:"message,omitempty"`
+	// Severity is mapped to a level through SeverityMap, else by common severity
+	// names (critical, error, warning, ...). Alerts are INFO without it.
+	Severity    string            `json:"severity,omitempty"`
+	SeverityMap map[string]string `json:"severity_map,omitempty"`
+	Fields      []WebhookField    `json:"fields,omitempty"`
+	Links       []WebhookLink     `json:"links,omitempty"`
+	// DedupeKey becomes the idempotency key when the request carries none
+	DedupeKey string `json:"dedupe_key,omitempty"`
+	Footer    string `json:"footer,omitempty"`
+
+	Destination
+}
+
+// WebhookField is a field extracted from the payload; empty values are left out
+type WebhookField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WebhookLink is an action button (see LinkAction) whose value is a URL extracted
+// from the payload. A path that yields a list of URLs gives one button per URL.
+type WebhookLink struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// WebhookAuth authenticates the requests of a webhook mapping
+type WebhookAuth struct {
+	// Type is "header" (shared secret), "hmac" (signed body) or "basic"
+	Type string `json:"type"`
+	// Header carries the secret or signature; defaults to X-Webhook-Token for
+	// "header" and X-Signature for "hmac"
+	Header string `json:"header,omitempty"`
+	Secret string `json:"secret,omitempty"`
+	// Algorithm is sha256 (default), sha1 or sha512
+	Algorithm string `json:"algorithm,omitempty"`
+	// Encoding of the signature: hex (default) or base64
+	Encoding string `json:"encoding,omitempty"`
+	// Prefix precedes the signature in the header, e.g. "sha256="
+	Prefix   string `json:"prefix,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// validate checks the auth method has what it needs
+func (a WebhookAuth) validate() error {
+	switch a.Type {
+	case "header":
+		if a.Secret == "" {
+			return fmt.Errorf("header auth needs a secret")
+		}
+	case "hmac":
+		if a.Secret == "" {
+			return fmt.Errorf("hmac auth needs a secret")
+		}
+		if _, err := a.hash(); err != nil {
+			return err
+		}
+		switch a.Encoding {
+		case "", "hex", "base64":
+		default:
+			return fmt.Errorf("unknown signature encoding %q", a.Encoding)
+		}
+	case "basic":
+		if a.Username == "" || a.Password == "" {
+			return fmt.Errorf("basic auth needs a username and password")
+		}
+	case "":
+		return fmt.Errorf("auth type is required (header, hmac or basic)")
+	default:
+		return fmt.Errorf("unknown auth type %q", a.Type)
+	}
+	return nil
+}
+
+// hash returns the HMAC hash function
+func (a WebhookAuth) hash() (func() hash.Hash, error) {
+	switch strings.ToLower(a.Algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unknown hmac algorithm %q", a.Algorithm)
+}
+
+// verify authenticates a request and its body
+func (a WebhookAuth) verify(r *http.Request, body []byte) error {
+	switch a.Type {
+	case "header":
+		header := a.Header
+		if header == "" {
+			header = defaultWebhookTokenHeader
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(header)), []byte(a.Secret)) != 1 {
+			return fmt.Errorf("invalid %s", header)
+		}
+
+	case "hmac":
+		header := a.Header
+		if header == "" {
+			header = defaultWebhookSignatureHeader
+		}
+		signature := r.Header.Get(header)
+		if signature == "" {
+			return fmt.Errorf("missing %s header", header)
+		}
+		signature, found := strings.CutPrefix(signature, a.Prefix)
+		if !found {
+			return fmt.Errorf("invalid signature")
+		}
+		newHash, _ := a.hash()
+		mac := hmac.New(newHash, []byte(a.Secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		if a.Encoding == "base64" {
+			expected = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		} else {
+			signature = strings.ToLower(signature)
+		}
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			return fmt.Errorf("invalid signature")
+		}
+
+	case "basic":
+		username, password, ok := r.BasicAuth()
+		userOK := subtle.ConstantTimeCompare([]byte(username), []byte(a.Username)) == 1
+		passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1
+		if !ok || !userOK || !passwordOK {
+			return fmt.Errorf("invalid credentials")
+		}
+
+	default:
+		return fmt.Errorf("unknown auth type %q", a.Type)
+	}
+	return nil
+}
+
+// webhookExpression is a compiled path or template expression. The zero value
+// extracts nothing.
+type webhookExpression struct {
+	path []pathStep
+	tmpl *template.Template
+}
+
+// compileWebhookExpression parses an expression
+func compileWebhookExpression(expr string) (webhookExpression, error) {
+	if expr == "" {
+		return webhookExpression{}, nil
+	}
+	if strings.Contains(expr, "{{") {
+		tmpl, err := template.New("").Funcs(templateFuncs).Option("missingkey=zero").Parse(expr)
+		if err != nil {
+			return webhookExpression{}, err
+		}
+		return webhookExpression{tmpl: tmpl}, nil
+	}
+	path, err := parseJSONPath(expr)
+	if err != nil {
+		return webhookExpression{}, err
+	}
+	if path == nil {
+		// "$" selects the whole payload
+		path = []pathStep{}
+	}
+	return webhookExpression{path: path}, nil
+}
+
+// value evaluates the expression; templates always yield text
+func (e webhookExpression) value(payload any) (any, error) {
+	switch {
+	case e.tmpl != nil:
+		var b bytes.Buffer
+		if err := e.tmpl.Execute(&b, payload); err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace(strings.ReplaceAll(b.String(), "<no value>", "")), nil
+	case e.path != nil:
+		return evalJSONPath(payload, e.path), nil
+	}
+	return nil, nil
+}
+
+// text evaluates the expression as text
+func (e webhookExpression) text(payload any) (string, error) {
+	v, err := e.value(payload)
+	return jsonText(v), err
+}
+
+// webhookMapper is a compiled WebhookConfig
+type webhookMapper struct {
+	name      string
+	config    *WebhookConfig
+	title     webhookExpression
+	message   webhookExpression
+	severity  webhookExpression
+	dedupeKey webhookExpression
+	fields    []webhookExpression
+	links     []webhookExpression
+}
+
+// newWebhookMappers compiles the expressions of every mapping
+func newWebhookMappers(configs map[string]*WebhookConfig) (map[string]*webhookMapper, error) {
+	mappers := make(map[string]*webhookMapper, len(configs))
+	for name, cfg := range configs {
+		m, err := newWebhookMapper(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", name, err)
+		}
+		mappers[name] = m
+	}
+	return mappers, nil
+}
+
+// newWebhookMapper validates a mapping and compiles its expressions
+func newWebhookMapper(name string, cfg *WebhookConfig) (*webhookMapper, error) {
+	if !webhookNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("name may only contain letters, digits, - and _")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("mapping is empty")
+	}
+	if err := cfg.Auth.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Title == "" {
+		return nil, fmt.Errorf("title expression is required")
+	}
+	for value, level := range cfg.SeverityMap {
+		if _, err := ParseAlertLevel(level); err != nil {
+			return nil, fmt.Errorf("severity_map %q: %w", value, err)
+		}
+	}
+	if err := cfg.Destination.validate(); err != nil {
+		return nil, err
+	}
+
+	m := &webhookMapper{name: name, config: cfg}
+	var err error
+	for _, expr := range []struct {
+		name string
+		text string
+		dst  *webhookExpression
+	}{
+		{"title", cfg.Title, &m.title},
+		{"message", cfg.Message, &m.message},
+		{"severity", cfg.Severity, &m.severity},
+		{"dedupe_key", cfg.DedupeKey, &m.dedupeKey},
+	} {
+		if *expr.dst, err = compileWebhookExpression(expr.text); err != nil {
+			return nil, fmt.Errorf("%s: %w", expr.name, err)
+		}
+	}
+	for _, field := range cfg.Fields {
+		e, err := compileWebhookExpression(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		m.fields = append(m.fields, e)
+	}
+	for _, link := range cfg.Links {
+		e, err := compileWebhookExpression(link.URL)
+		if err != nil {
+			return nil, fmt.Errorf("link %q: %w", link.Text, err)
+		}
+		m.links = append(m.links, e)
+	}
+	return m, nil
+}
+
+// WebhookExtraction holds the values a mapping extracted from a payload
+type WebhookExtraction struct {
+	Title     string     `json:"title"`
+	Message   string     `json:"message,omitempty"`
+	Severity  string     `json:"severity,omitempty"`
+	Level     AlertLevel `json:"level"`
+	Fields    []Field    `json:"fields,omitempty"`
+	Links     []Action   `json:"links,omitempty"`
+	DedupeKey string     `json:"dedupe_key,omitempty"`
+}
+
+// alert builds the alert sent for the extracted values
+func (x WebhookExtraction) alert(footer string) Alert {
+	return Alert{
+		Title:   x.Title,
+		Level:   x.Level,
+		Text:    x.Message,
+		Fields:  x.Fields,
+		Actions: x.Links,
+		Footer:  footer,
+	}
+}
+
+// extract evaluates the mapping's expressions against a payload
+func (m *webhookMapper) extract(payload any) (WebhookExtraction, error) {
+	var x WebhookExtraction
+	var err error
+	if x.Title, err = m.title.text(payload); err != nil {
+		return x, fmt.Errorf("title: %w", err)
+	}
+	if x.Message, err = m.message.text(payload); err != nil {
+		return x, fmt.Errorf("message: %w", err)
+	}
+	if x.Severity, err = m.severity.text(payload); err != nil {
+		return x, fmt.Errorf("severity: %w", err)
+	}
+	x.Level = m.level(x.Severity)
+	if x.DedupeKey, err = m.dedupeKey.text(payload); err != nil {
+		return x, fmt.Errorf("dedupe_key: %w", err)
+	}
+
+	for i, e := range m.fields {
+		value, err := e.text(payload)
+		if err != nil {
+			return x, fmt.Errorf("field %q: %w", m.config.Fields[i].Name, err)
+		}
+		if value != "" {
+			x.Fields = append(x.Fields, Field{Key: m.config.Fields[i].Name, Value: value})
+		}
+	}
+	for i, e := range m.links {
+		value, err := e.value(payload)
+		if err != nil {
+			return x, fmt.Errorf("link %q: %w", m.config.Links[i].Text, err)
+		}
+		urls, isList := value.([]any)
+		if !isList {
+			urls = []any{value}
+		}
+		for _, u := range urls {
+			if text := jsonText(u); text != "" {
+				x.Links = append(x.Links, LinkAction(m.config.Links[i].Text, text))
+			}
+		}
+	}
+	return x, nil
+}
+
+// level maps an extracted severity to an alert level
+func (m *webhookMapper) level(severity string) AlertLevel {
+	if severity == "" {
+		return AlertLevelInfo
+	}
+	mapped, ok := m.config.SeverityMap[severity]
+	if !ok {
+		mapped, ok = m.config.SeverityMap[strings.ToLower(severity)]
+	}
+	if ok {
+		level, _ := ParseAlertLevel(mapped)
+		return level
+	}
+	if level, err := ParseAlertLevel(severity); err == nil {
+		return level
+	}
+	return levelFromSeverity(severity)
+}
+
+// MappedWebhook receives the payloads of a configured webhook mapping. With
+// ?dry_run=true it responds with the extracted values and the rendered content
+// instead of sending.
+func (h *AlertHandler) MappedWebhook(w http.ResponseWriter, r *http.Request) {
+	m, ok := h.webhooks[r.PathValue("name")]
+	if !ok {
+		integrationNotConfigured(w, "Webhook "+r.PathValue("name"))
+		return
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": err.Error()})
+		return
+	}
+	if err := m.config.Auth.verify(r, body); err != nil {
+		if m.config.Auth.Type == "basic" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="zoomalert"`)
+		}
+		writeJSON(w, http.StatusUnauthorized, jsonObject{"error": err.Error()})
+		return
+	}
+
+	var payload any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Keep large IDs and timestamps exact
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonObject{"error": "Invalid JSON payload: " + err.Error()})
+		return
+	}
+
+	extraction, err := m.extract(payload)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	} else if extraction.Title == "" {
+		err = fmt.Errorf("%w: title expression %q produced no value", ErrInvalidMessage, m.config.Title)
+	}
+
+	if parseBool(r.URL.Query().Get("dry_run")) {
+		resp := jsonObject{
+			"dry_run":    true,
+			"extracted":  extraction,
+			"content":    extraction.alert(m.config.Footer).Content(),
+			"recipients": m.config.Destination.recipients(),
+		}
+		if err != nil {
+			resp["error"] = err.Error()
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		writeSendResults(w, nil, err)
+		return
+	}
+
+	alert := extraction.alert(m.config.Footer)
+	o := integrationSendOptions(r, m.config.Destination)
+	o.source = "webhook:" + m.name
+	if o.idempotencyKey == "" && extraction.DedupeKey != "" {
+		o.idempotencyKey = "webhook:" + m.name + ":" + extraction.DedupeKey
+	}
+	h.dispatch(w, r, m.config.Destination.recipients(), message{content: alert.Content(), level: alert.Level}, o)
+}
@@ -0,0 +1,174 @@
+package zoomalert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func newTestWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		Auth:     WebhookAuth{Type: "header", Secret: testWebhookSecret},
+		Title:    "{{ .alert.name }} on {{ .alert.host | default \"unknown host\" }}",
+		Message:  "$.alert.description",
+		Severity: "alert.severity",
+		SeverityMap: map[string]string{
+			"p1": "CRITICAL",
+		},
+		Fields: []WebhookField{
+			{Name: "Tags", Value: "alert.tags[*]"},
+			{Name: "Owner", Value: "alert.owner"},
+		},
+		Links: []WebhookLink{
+			{Text: "Runbook", URL: "alert.runbook"},
+			{Text: "Graph", URL: "alert.graphs[*].url"},
+		},
+		DedupeKey:   "alert.id",
+		Destination: Destination{Channels: []string{"sre@conference.xmpp.zoom.us"}},
+	}
+}
+
+func TestNewWebhookMapperTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*WebhookConfig)
+		wantErr   string
+	}{
+		{"unclosed action", func(c *WebhookConfig) { c.Title = "{{ .alert.name" }, "title"},
+		{"unknown function", func(c *WebhookConfig) { c.Message = "{{ nosuch .alert }}" }, "message"},
+		{"bad field path", func(c *WebhookConfig) { c.Fields[0].Value = "alert.tags[x]" }, `field "Tags"`},
+		{"bad link template", func(c *WebhookConfig) { c.Links[0].URL = "{{ end }}" }, `link "Runbook"`},
+		{"missing title", func(c *WebhookConfig) { c.Title = "" }, "title expression is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestWebhookConfig()
+			tt.configure(cfg)
+			_, err := newWebhookMapper("tool", cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookMapperExtract(t *testing.T) {
+	m, err := newWebhookMapper("tool", newTestWebhookConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload any
+	json.Unmarshal([]byte(`{"alert": {
+		"id": "a-1", "name": "High CPU", "description": "cpu at 97%", "severity": "P1",
+		"tags": ["prod", "db"], "runbook": "https://runbooks.example.com/cpu",
+		"graphs": [{"url": "https://g.example.com/1"}, {"title": "no url"}, {"url": "https://g.example.com/2"}]
+	}}`), &payload)
+
+	x, err := m.extract(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Title != "High CPU on unknown host" {
+		t.Errorf("title = %q", x.Title)
+	}
+	if x.Message != "cpu at 97%" || x.Severity != "P1" || x.DedupeKey != "a-1" {
+		t.Errorf("message, severity, dedupe key = %q, %q, %q", x.Message, x.Severity, x.DedupeKey)
+	}
+	if x.Level != AlertLevelCritical {
+		t.Errorf("level = %s, want %s from severity_map", x.Level, AlertLevelCritical)
+	}
+	// The missing owner is left out
+	if len(x.Fields) != 1 || x.Fields[0] != (Field{Key: "Tags", Value: "prod, db"}) {
+		t.Errorf("fields = %+v", x.Fields)
+	}
+	want := []Action{
+		LinkAction("Runbook", "https://runbooks.example.com/cpu"),
+		LinkAction("Graph", "https://g.example.com/1"),
+		LinkAction("Graph", "https://g.example.com/2"),
+	}
+	if len(x.Links) != len(want) {
+		t.Fatalf("links = %+v, want %+v", x.Links, want)
+	}
+	for i := range want {
+		if x.Links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, x.Links[i], want[i])
+		}
+	}
+}
+
+func TestMappedWebhookDryRun(t *testing.T) {
+	cfg := newTestWebhookConfig()
+	cfg.Fields = append(cfg.Fields, WebhookField{Name: "Third tag", Value: "{{ index .alert.tags 2 }}"})
+
+	config := DefaultConfig()
+	config.ZoomAccountID = "account"
+	config.ZoomClientID = "client"
+	config.ZoomClientSecret = "secret"
+	config.TokenFilePath = filepath.Join(t.TempDir(), "tokens.json")
+	untitled := newTestWebhookConfig()
+	untitled.Title = "alert.name"
+	config.Integrations.Webhooks = map[string]*WebhookConfig{"tool": cfg, "untitled": untitled}
+	module, err := NewZoomAlertModule(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer module.Shutdown()
+	handler := module.Handler()
+
+	post := func(path, body string) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(defaultWebhookTokenHeader, testWebhookSecret)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	t.Run("extracted values", func(t *testing.T) {
+		rec, resp := post("/webhooks/tool?dry_run=true",
+			`{"alert": {"name": "Disk full", "host": "db-1", "severity": "warning", "tags": ["a", "b", "c"]}}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if resp["error"] != nil {
+			t.Fatalf("unexpected error %v", resp["error"])
+		}
+		extracted, _ := resp["extracted"].(map[string]any)
+		if extracted["title"] != "Disk full on db-1" || extracted["level"] != string(AlertLevelWarning) {
+			t.Fatalf("extracted = %v", extracted)
+		}
+		if content, _ := json.Marshal(resp["content"]); !strings.Contains(string(content), "Disk full on db-1") {
+			t.Fatalf("content = %s, want the rendered title", content)
+		}
+	})
+
+	// Template errors are reported instead of sent
+	t.Run("template error", func(t *testing.T) {
+		rec, resp := post("/webhooks/tool?dry_run=true", `{"alert": {"name": "Disk full", "tags": ["a"]}}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if msg, _ := resp["error"].(string); !strings.Contains(msg, `field "Third tag"`) {
+			t.Fatalf("error = %v, want the failing field", resp["error"])
+		}
+
+		rec, _ = post("/webhooks/tool", `{"alert": {"name": "Disk full", "tags": ["a"]}}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+		}
+	})
+
+	t.Run("empty title", func(t *testing.T) {
+		_, resp := post("/webhooks/untitled?dry_run=true", `{"alert": {"tags": ["a"]}}`)
+		if msg, _ := resp["error"].(string); !strings.Contains(msg, "produced no value") {
+			t.Fatalf("error = %v, want an empty title error", resp["error"])
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -17,6 +18,10 @@ type ZoomService struct {
 	robotJID     string
 	accountID    string
 	logger       *slog.Logger
+	// Chatbots by name and their cached client-credentials tokens
+	bots          map[string]BotConfig
+	chatbotTokens map[string]cachedToken
+	chatbotMutex  sync.Mutex
 }
 
 // User represents a Zoom user
@@ -26,6 +31,7 @@ type User struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	JID       string `json:"jid"`
+	AccountID string `json:"account_id,omitempty"`
 }
 
 // UserResponse represents the response from user search
@@ -33,22 +39,59 @@ type UserResponse struct {
 	Users []User `json:"users"`
 }
 
-
 // ChatResponse represents the response from sending a chat message
 type ChatResponse struct {
 	ID        string `json:"id"`
+	MessageID string `json:"message_id"`
 	Message   string `json:"message"`
 	Timestamp string `json:"timestamp"`
 }
 
+// Recipient identifies who receives a message: a user by email or a channel by JID
+type Recipient struct {
+	Email      string `json:"email,omitempty"`
+	ChannelJID string `json:"channel,omitempty"`
+}
+
+// String returns the recipient for logging
+func (r Recipient) String() string {
+	if r.ChannelJID != "" {
+		return "channel:" + r.ChannelJID
+	}
+	return r.Email
+}
+
+// Validate checks that exactly one of email and channel is set
+func (r Recipient) Validate() error {
+	if r.Email == "" && r.ChannelJID == "" {
+		return fmt.Errorf("email or channel is required")
+	}
+	if r.Email != "" && r.ChannelJID != "" {
+		return fmt.Errorf("email and channel are mutually exclusive")
+	}
+	return nil
+}
+
+// SendResult describes a delivered message
+type SendResult struct {
+	MessageID string    `json:"message_id,omitempty"`
+	Recipient Recipient `json:"recipient"`
+	// Error is set for recipients that failed in a multi-recipient send
+	Error string `json:"error,omitempty"`
+	// Replayed is true when the result was returned for a repeated idempotency key
+	Replayed bool `json:"replayed,omitempty"`
+}
+
 // NewZoomService creates a new ZoomService
 func NewZoomService(oauthService *OAuthService, robotJID, accountID string, logger *slog.Logger) *ZoomService {
 	return &ZoomService{
-		oauthService: oauthService,
-		baseURL:      "https://api.zoom.us/v2",
-		robotJID:     robotJID,
-		accountID:    accountID,
-		logger:       logger,
+		oauthService:  oauthService,
+		baseURL:       "https://api.zoom.us/v2",
+		robotJID:      robotJID,
+		accountID:     accountID,
+		logger:        logger,
+		bots:          oauthService.GetConfig().Bots,
+		chatbotTokens: make(map[string]cachedToken),
 	}
 }
 
@@ -93,16 +136,16 @@ func (z *ZoomService) getUserByEmail(email string) (*User, error) {
 	return &user, nil
 }
 
-// postMessage sends a chat message
-func (z *ZoomService) postMessage(message zoomMessage) error {
-	token, err := z.getChatbotToken()
+// postMessage sends a chat message as the given bot and returns Zoom's message ID
+func (z *ZoomService) postMessage(bot BotConfig, message zoomMessage) (string, error) {
+	token, err := z.getChatbotToken(bot)
 	if err != nil {
-		return fmt.Errorf("failed to get chatbot token: %w", err)
+		return "", fmt.Errorf("failed to get chatbot token: %w", err)
 	}
 	// Prepare chat message
 	jsonData, err := json.Marshal(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal chat message: %w", err)
+		return "", fmt.Errorf("failed to marshal chat message: %w", err)
 	}
 
 	// Send chat message using chatbot token
@@ -110,7 +153,7 @@ func (z *ZoomService) postMessage(message zoomMessage) error {
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
@@ -118,7 +161,7 @@ func (z *ZoomService) postMessage(message zoomMessage) error {
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return "", fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -126,7 +169,7 @@ func (z *ZoomService) postMessage(message zoomMessage) error {
 	var respBody bytes.Buffer
 	_, err = respBody.ReadFrom(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	slog.Debug("HTTP response details (chatbot token)",
@@ -138,74 +181,35 @@ func (z *ZoomService) postMessage(message zoomMessage) error {
 	resp.Body = io.NopCloser(bytes.NewReader(respBody.Bytes()))
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("chat message request failed with status: %d, body: %s",
+		return "", fmt.Errorf("chat message request failed with status: %d, body: %s",
 			resp.StatusCode, respBody.String())
 	}
 
-	return nil
-}
-
-// postText sends a chat message using chatbot token
-func (z *ZoomService) postText(userJID, message string) error {
-	token, err := z.getChatbotToken()
-	if err != nil {
-		return fmt.Errorf("failed to get chatbot token: %w", err)
-	}
-	// Prepare chat message
-	chatMsg := zoomMessage{
-		RobotJID:  z.robotJID,
-		ToJID:     userJID,
-		AccountID: z.accountID,
-		Content: ZoomContent{
-			Head: ZoomHead{
-				Text: message,
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(chatMsg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal chat message: %w", err)
+	var chatResp ChatResponse
+	if err := json.Unmarshal(respBody.Bytes(), &chatResp); err != nil {
+		// The message was delivered; a missing ID is not worth failing the send
+		z.logger.Warn("failed to decode chat message response", "error", err)
 	}
-
-	// Send chat message using chatbot token
-	url := fmt.Sprintf("%s/im/chat/messages", z.baseURL)
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if chatResp.MessageID == "" {
+		chatResp.MessageID = chatResp.ID
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
+	return chatResp.MessageID, nil
+}
 
-	// Read and log response body
-	var respBody bytes.Buffer
-	_, err = respBody.ReadFrom(resp.Body)
+// postText sends a plain text chat message as the given bot
+func (z *ZoomService) postText(bot BotConfig, userJID, message string) error {
+	chatMsg, err := z.buildMessage(bot, userJID, ZoomContent{
+		Head: ZoomHead{
+			Text: message,
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	slog.Debug("HTTP response details (chatbot token)",
-		"status", resp.Status,
-		"statusCode", resp.StatusCode,
-		"body", respBody.String())
-
-	// Restore response body for potential further processing
-	resp.Body = io.NopCloser(bytes.NewReader(respBody.Bytes()))
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("chat message request failed with status: %d, body: %s",
-			resp.StatusCode, respBody.String())
+		return fmt.Errorf("failed to build message: %w", err)
 	}
 
-	return nil
+	_, err = z.postMessage(bot, chatMsg)
+	return err
 }
 
 // GetAuthorizationURL generates the authorization URL for OAuth flow
@@ -214,12 +218,22 @@ func (z *ZoomService) GetAuthorizationURL(state string) string {
 }
 
 // exchangeCodeForToken exchanges authorization code for access token
-func (z *ZoomService) exchangeCodeForToken(code string) error {
-	return z.oauthService.ExchangeCodeForToken(code)
+func (z *ZoomService) exchangeCodeForToken(code, codeVerifier string) error {
+	return z.oauthService.ExchangeCodeForToken(code, codeVerifier)
 }
 
 // PostTextByEmail sends alert using user authorization token (required for user lookup)
 func (z *ZoomService) PostTextByEmail(email, message string) error {
+	return z.PostTextByEmailAs("", email, message)
+}
+
+// PostTextByEmailAs sends a text alert from the named bot (empty for the default bot)
+func (z *ZoomService) PostTextByEmailAs(botName, email, message string) error {
+	bot, err := z.bot(botName)
+	if err != nil {
+		return err
+	}
+
 	// First, get the user by email using user token
 	user, err := z.getUserByEmail(email)
 	if err != nil {
@@ -228,19 +242,22 @@ func (z *ZoomService) PostTextByEmail(email, message string) error {
 	}
 
 	// Then send the chat message using chatbot token and user's JID
-	if err := z.postText(user.JID, message); err != nil {
+	if err := z.postText(bot, user.JID, message); err != nil {
 		return fmt.Errorf("failed to send chat message with user token: %w", err)
 	}
 
 	return nil
 }
 
-// buildMessage sends a rich message to a Zoom user by JID
-func (z *ZoomService) buildMessage(userJID string, message ZoomContent) (zoomMessage, error) {
+// buildMessage prepares a rich message from a bot to a Zoom user or channel by JID
+func (z *ZoomService) buildMessage(bot BotConfig, userJID string, message ZoomContent) (zoomMessage, error) {
+	if bot.RobotJID == "" {
+		return zoomMessage{}, fmt.Errorf("no robot JID configured for bot")
+	}
 
 	// Prepare chat message
 	chatMsg := zoomMessage{
-		RobotJID:  z.robotJID,
+		RobotJID:  bot.RobotJID,
 		ToJID:     userJID,
 		AccountID: z.accountID,
 		Content:   message,
@@ -251,25 +268,54 @@ func (z *ZoomService) buildMessage(userJID string, message ZoomContent) (zoomMes
 
 // SendMessageByEmail sends a rich message to a Zoom user by email
 func (z *ZoomService) SendMessageByEmail(email string, message ZoomContent) error {
-	// First, get the user by email using user token
-	user, err := z.getUserByEmail(email)
+	return z.SendMessageByEmailAs("", email, message)
+}
+
+// SendMessageByEmailAs sends a rich message from the named bot (empty for the default bot)
+func (z *ZoomService) SendMessageByEmailAs(botName, email string, message ZoomContent) error {
+	_, err := z.Send(botName, Recipient{Email: email}, message)
+	return err
+}
+
+// Send delivers a rich message from the named bot to a user (looked up by email with
+// the user token) or directly to a channel JID, returning Zoom's message ID
+func (z *ZoomService) Send(botName string, to Recipient, message ZoomContent) (*SendResult, error) {
+	if err := to.Validate(); err != nil {
+		return nil, err
+	}
+
+	bot, err := z.bot(botName)
 	if err != nil {
-		slog.Error("Failed to get user with user token", "email", email, "error", err)
-		return fmt.Errorf("failed to get user with user token: %w", err)
+		return nil, err
+	}
+
+	toJID := to.ChannelJID
+	if to.Email != "" {
+		// First, get the user by email using user token
+		user, err := z.getUserByEmail(to.Email)
+		if err != nil {
+			slog.Error("Failed to get user with user token", "email", to.Email, "error", err)
+			return nil, fmt.Errorf("failed to get user with user token: %w", err)
+		}
+		toJID = user.JID
 	}
 
-	// Build the message for the user
-	chatMsg, err := z.buildMessage(user.JID, message)
+	// Build the message for the recipient
+	chatMsg, err := z.buildMessage(bot, toJID, message)
 	if err != nil {
-		return fmt.Errorf("failed to build message: %w", err)
+		return nil, fmt.Errorf("failed to build message: %w", err)
 	}
 
 	// Then send the chat message using chatbot token
-	if err := z.postMessage(chatMsg); err != nil {
-		return fmt.Errorf("failed to send chat message with user token: %w", err)
+	messageID, err := z.postMessage(bot, chatMsg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
 	}
 
-	return nil
+	return &SendResult{
+		MessageID: messageID,
+		Recipient: to,
+	}, nil
 }
 
 // IsUserAuthorized checks if user authorization is available
@@ -277,27 +323,49 @@ func (z *ZoomService) IsUserAuthorized() bool {
 	return z.oauthService.IsUserAuthorized()
 }
 
+// AuthStatus returns details about the current user authorization
+func (z *ZoomService) AuthStatus() AuthStatus {
+	return z.oauthService.Status()
+}
+
+// revokeAuthorization revokes the user authorization at Zoom and clears stored tokens
+func (z *ZoomService) revokeAuthorization() error {
+	return z.oauthService.Revoke()
+}
+
 // generateOAuthState generates a secure state parameter for OAuth flow
-func (z *ZoomService) generateOAuthState() (string, error) {
-	return z.oauthService.GenerateState()
+func (z *ZoomService) generateOAuthState(returnTo string) (string, error) {
+	return z.oauthService.GenerateStateWithReturnTo(returnTo)
 }
 
-// validateOAuthState validates and consumes an OAuth state parameter
-func (z *ZoomService) validateOAuthState(state string) error {
-	return z.oauthService.ValidateState(state)
+// consumeOAuthState validates and consumes an OAuth state parameter
+func (z *ZoomService) consumeOAuthState(state string) (StateInfo, error) {
+	return z.oauthService.ConsumeState(state)
 }
 
-// getChatbotToken gets an access token using client credentials flow for chatbot operations
-func (z *ZoomService) getChatbotToken() (string, error) {
-	// Get client credentials from oauth service's config
+// getChatbotToken gets an access token using client credentials flow for chatbot operations.
+// Tokens are cached per client ID until shortly before they expire.
+func (z *ZoomService) getChatbotToken(bot BotConfig) (string, error) {
+	// Bots without their own credentials use the account's client credentials
 	config := z.oauthService.GetConfig()
 	clientID := config.ZoomClientID
 	clientSecret := config.ZoomClientSecret
+	if bot.ClientID != "" {
+		clientID = bot.ClientID
+		clientSecret = bot.ClientSecret
+	}
 
 	if clientID == "" || clientSecret == "" {
 		return "", fmt.Errorf("client credentials not configured")
 	}
 
+	z.chatbotMutex.Lock()
+	defer z.chatbotMutex.Unlock()
+
+	if cached, ok := z.chatbotTokens[clientID]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
 	// Prepare request for client credentials flow
 	url := "https://zoom.us/oauth/token?grant_type=client_credentials"
 
@@ -331,5 +399,10 @@ func (z *ZoomService) getChatbotToken() (string, error) {
 		return "", fmt.Errorf("failed to decode token response: %w", err)
 	}
 
+	z.chatbotTokens[clientID] = cachedToken{
+		token:     tokenResponse.AccessToken,
+		expiresAt: time.Now().Add(time.Duration(tokenResponse.ExpiresIn-60) * time.Second),
+	}
+
 	return tokenResponse.AccessToken, nil
 }
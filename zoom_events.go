@@ -0,0 +1,140 @@
+package zoomalert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Zoom webhook event types handled by the module
+const (
+	zoomEventURLValidation   = "endpoint.url_validation"
+	zoomEventAppDeauthorized = "app_deauthorized"
+)
+
+// zoomEventMaxSkew is the maximum accepted age of a Zoom webhook timestamp
+const zoomEventMaxSkew = 5 * time.Minute
+
+// ZoomEvent is the envelope of a Zoom webhook event notification
+type ZoomEvent struct {
+	Event   string          `json:"event"`
+	EventTS int64           `json:"event_ts"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// urlValidationPayload is sent by Zoom when the event endpoint is registered
+type urlValidationPayload struct {
+	PlainToken string `json:"plainToken"`
+}
+
+// urlValidationResponse answers Zoom's endpoint URL validation challenge
+type urlValidationResponse struct {
+	PlainToken     string `json:"plainToken"`
+	EncryptedToken string `json:"encryptedToken"`
+}
+
+// DeauthorizationPayload is sent by Zoom when a user removes the app
+type DeauthorizationPayload struct {
+	AccountID           string `json:"account_id"`
+	UserID              string `json:"user_id"`
+	ClientID            string `json:"client_id"`
+	Signature           string `json:"signature"`
+	DeauthorizationTime string `json:"deauthorization_time"`
+}
+
+// verifyZoomEventSignature checks the x-zm-signature header of a webhook request
+func verifyZoomEventSignature(secret, timestamp, signature string, body []byte) error {
+	if secret == "" {
+		return fmt.Errorf("zoom webhook secret token is not configured")
+	}
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing Zoom signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Zoom request timestamp: %w", err)
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > zoomEventMaxSkew || age < -zoomEventMaxSkew {
+		return fmt.Errorf("zoom request timestamp outside allowed window")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid Zoom signature")
+	}
+	return nil
+}
+
+// answerURLValidation builds the response to Zoom's endpoint validation challenge
+func answerURLValidation(secret string, payload json.RawMessage) (urlValidationResponse, error) {
+	var challenge urlValidationPayload
+	if err := json.Unmarshal(payload, &challenge); err != nil {
+		return urlValidationResponse{}, fmt.Errorf("failed to decode validation payload: %w", err)
+	}
+	if challenge.PlainToken == "" {
+		return urlValidationResponse{}, fmt.Errorf("validation payload has no plainToken")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(challenge.PlainToken))
+
+	return urlValidationResponse{
+		PlainToken:     challenge.PlainToken,
+		EncryptedToken: hex.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// deauthorizesStoredTokens reports whether a deauthorization is for the user and
+// account that authorized the stored tokens. Each user of a user-level app sends
+// its own event, so only a match clears the tokens.
+func (z *ZoomService) deauthorizesStoredTokens(deauth DeauthorizationPayload) bool {
+	if authorizer := z.oauthService.authorizer(); authorizer != nil {
+		if authorizer.ID != "" && deauth.UserID != authorizer.ID {
+			return false
+		}
+		if authorizer.AccountID != "" && deauth.AccountID != authorizer.AccountID {
+			return false
+		}
+		if authorizer.ID != "" || authorizer.AccountID != "" {
+			return true
+		}
+	}
+	// Without a recorded authorizer, only the configured account identifies our tokens
+	return z.accountID != "" && deauth.AccountID == z.accountID
+}
+
+// handleDeauthorization clears stored tokens when the authorizing user removes the app
+func (z *ZoomService) handleDeauthorization(payload json.RawMessage) error {
+	var deauth DeauthorizationPayload
+	if err := json.Unmarshal(payload, &deauth); err != nil {
+		return fmt.Errorf("failed to decode deauthorization payload: %w", err)
+	}
+
+	config := z.oauthService.GetConfig()
+	if deauth.ClientID != "" && deauth.ClientID != config.ZoomClientID {
+		z.logger.Info("Ignoring deauthorization for another app", "client_id", deauth.ClientID)
+		return nil
+	}
+	if !z.deauthorizesStoredTokens(deauth) {
+		z.logger.Info("Ignoring deauthorization for another user or account",
+			"account_id", deauth.AccountID,
+			"user_id", deauth.UserID)
+		return nil
+	}
+
+	z.logger.Warn("Zoom app deauthorized, clearing stored tokens",
+		"account_id", deauth.AccountID,
+		"user_id", deauth.UserID)
+
+	return z.oauthService.ClearTokens()
+}